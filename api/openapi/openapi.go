@@ -0,0 +1,52 @@
+// Package openapi embeds OpenAPI specification of the HTTP API together with the page rendering it.
+package openapi
+
+//go:generate ./vendor-swagger-ui.sh
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/uuid"
+)
+
+var (
+	//go:embed openapi.yaml
+	spec []byte
+
+	// Docs is the page rendering the specification served at /openapi.json with Swagger UI.
+	//go:embed docs.html
+	Docs []byte
+
+	//go:embed swagger-ui
+	swaggerUI embed.FS
+
+	// SwaggerUI holds the Swagger UI assets loaded by Docs, they are vendored by vendor-swagger-ui.sh.
+	SwaggerUI, _ = fs.Sub(swaggerUI, "swagger-ui")
+)
+
+func init() {
+	// uuid format is validated the same way ids are parsed by the handlers
+	openapi3.DefineStringFormatCallback("uuid", func(value string) error {
+		_, err := uuid.Parse(value)
+
+		return err
+	})
+}
+
+// Load parses the specification and checks that it's valid.
+func Load(ctx context.Context) (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi: error parsing specification: %w", err)
+	}
+
+	if err := doc.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("load openapi: invalid specification: %w", err)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,15 @@
+#!/bin/sh
+# Downloads the Swagger UI assets of the version pinned in swagger-ui/VERSION, they are embedded into the binary,
+# so the docs page doesn't depend on a CDN.
+set -eu
+
+cd "$(dirname "$0")/swagger-ui"
+VERSION="$(cat VERSION)"
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$VERSION.tgz" | tar -xz -C "$TMP"
+
+for file in swagger-ui.css swagger-ui-bundle.js LICENSE; do
+  cp "$TMP/package/$file" .
+done
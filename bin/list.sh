@@ -0,0 +1,2 @@
+#!/bin/sh
+curl -i -X GET "http://localhost:8080/companies?limit=10&sort=name$1"
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/google/uuid"
+)
+
+// defaultActor is recorded in the history of companies changed by the CLI unless -actor is set.
+const defaultActor = "system:companyctl"
+
+func (e *environment) companyService() (ports.CompanyService, error) {
+	repo, err := e.postgres()
+	if err != nil {
+		return nil, err
+	}
+
+	return services.NewCompanyService(e.cfg.AppName, repo, metrics.NewPrometheus(), e.logger), nil
+}
+
+// companyFlags registers flags of the company fields, patch holds only the flags which were set.
+type companyFlags struct {
+	fs      *flag.FlagSet
+	company domain.Company
+}
+
+func newCompanyFlags(fs *flag.FlagSet) *companyFlags {
+	cf := &companyFlags{fs: fs}
+	fs.StringVar(&cf.company.Name, "name", "", "name of the company")
+	fs.StringVar(&cf.company.Description, "description", "", "description of the company")
+	fs.IntVar(&cf.company.EmployeeCnt, "employee_cnt", 0, "amount of employees")
+	fs.BoolVar(&cf.company.Registered, "registered", false, "whether the company is registered")
+	fs.StringVar(&cf.company.Type, "type", "", "type of the company")
+
+	return cf
+}
+
+func (cf *companyFlags) patch() domain.CompanyPatch {
+	var patch domain.CompanyPatch
+
+	cf.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			patch.Name = &cf.company.Name
+		case "description":
+			patch.Description = &cf.company.Description
+		case "employee_cnt":
+			patch.EmployeeCnt = &cf.company.EmployeeCnt
+		case "registered":
+			patch.Registered = &cf.company.Registered
+		case "type":
+			patch.Type = &cf.company.Type
+		}
+	})
+
+	return patch
+}
+
+func runCompany(ctx context.Context, env *environment, args []string) error {
+	const subcommands = "create|get|list|update|delete|restore|history"
+
+	if len(args) == 0 {
+		return usageError("company", subcommands)
+	}
+
+	name, args := args[0], args[1:]
+	if name == "list" {
+		return listCompanies(ctx, env, args)
+	}
+
+	argsUsage := "<id>"
+	if name == "create" {
+		argsUsage = ""
+	}
+
+	fs := newFlagSet("company "+name, argsUsage)
+	actor := fs.String("actor", defaultActor, "actor recorded in the company history")
+
+	switch name {
+	case "create":
+		cf := newCompanyFlags(fs)
+
+		if err := parseFlags(fs, args, 0, 0); err != nil {
+			return err
+		}
+
+		return withService(ctx, env, *actor, func(ctx context.Context, cs ports.CompanyService) error {
+			company := cf.company
+			if err := cs.Create(ctx, &company); err != nil {
+				return err
+			}
+
+			return env.print(&company)
+		})
+	case "get", "restore", "history":
+		if err := parseFlags(fs, args, 1, 1); err != nil {
+			return err
+		}
+
+		id, err := parseID(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		return withService(ctx, env, *actor, func(ctx context.Context, cs ports.CompanyService) error {
+			var result any
+
+			switch name {
+			case "get":
+				result, err = cs.Get(ctx, id)
+			case "restore":
+				result, err = cs.Restore(ctx, id)
+			default:
+				result, err = cs.History(ctx, id)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			return env.print(result)
+		})
+	case "update":
+		cf := newCompanyFlags(fs)
+		version := fs.Int("version", 0, "expected version of the company, 0 skips the check")
+
+		if err := parseFlags(fs, args, 1, 1); err != nil {
+			return err
+		}
+
+		id, err := parseID(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		return withService(ctx, env, *actor, func(ctx context.Context, cs ports.CompanyService) error {
+			if err := cs.Update(ctx, id, *version, cf.patch()); err != nil {
+				return err
+			}
+
+			company, err := cs.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			return env.print(company)
+		})
+	case "delete":
+		version := fs.Int("version", 0, "expected version of the company, 0 skips the check")
+
+		if err := parseFlags(fs, args, 1, 1); err != nil {
+			return err
+		}
+
+		id, err := parseID(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		return withService(ctx, env, *actor, func(ctx context.Context, cs ports.CompanyService) error {
+			return cs.Delete(ctx, id, *version)
+		})
+	default:
+		return usageError("company", subcommands)
+	}
+}
+
+func listCompanies(ctx context.Context, env *environment, args []string) error {
+	fs := newFlagSet("company list", "")
+	companyType := fs.String("type", "", "list only companies of the type")
+	namePrefix := fs.String("name_prefix", "", "list only companies with names starting with the prefix")
+	limit := fs.Int("limit", domain.ListDefaultLimit, "page size")
+	cursor := fs.String("cursor", "", "next_cursor of the previous page")
+	desc := fs.Bool("desc", false, "order companies by name descending")
+
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	params := domain.ListParams{
+		Filter:     domain.CompanyFilter{NamePrefix: *namePrefix},
+		Descending: *desc,
+		Limit:      *limit,
+	}
+
+	if *companyType != "" {
+		params.Filter.Type = companyType
+	}
+
+	if *cursor != "" {
+		after, err := domain.DecodeListCursor(*cursor)
+		if err != nil {
+			return err
+		}
+
+		params.After = after
+	}
+
+	return withService(ctx, env, defaultActor, func(ctx context.Context, cs ports.CompanyService) error {
+		page, err := cs.List(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		return env.print(page)
+	})
+}
+
+// withService calls fn with the company service and the context of the actor.
+func withService(
+	ctx context.Context,
+	env *environment,
+	actor string,
+	fn func(ctx context.Context, cs ports.CompanyService) error,
+) error {
+	cs, err := env.companyService()
+	if err != nil {
+		return err
+	}
+
+	return fn(domain.WithActor(ctx, actor), cs)
+}
+
+func parseID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid company id \"%s\": %w", s, err)
+	}
+
+	return id, nil
+}
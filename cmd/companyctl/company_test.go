@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompanyFlags(t *testing.T) {
+	t.Parallel()
+
+	fs := newFlagSet("company update", "<id>")
+	cf := newCompanyFlags(fs)
+
+	require.NoError(t, parseFlags(fs, []string{"-name", "renamed", "-registered=false", "-employee_cnt", "0", "id"}, 1, 1))
+
+	name, registered, employeeCnt := "renamed", false, 0
+	assert.Equal(t, domain.CompanyPatch{Name: &name, Registered: &registered, EmployeeCnt: &employeeCnt}, cf.patch(),
+		"patch has to hold only the flags which were set, including zero values")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func runEvents(ctx context.Context, env *environment, args []string) error {
+	if len(args) == 0 || args[0] != "replay" {
+		return usageError("events", "replay")
+	}
+
+	fs := newFlagSet("events replay", "<id>...")
+	if err := parseFlags(fs, args[1:], 1, -1); err != nil {
+		return err
+	}
+
+	ids := make([]uuid.UUID, 0, fs.NArg())
+
+	for _, arg := range fs.Args() {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	repo, err := env.postgres()
+	if err != nil {
+		return err
+	}
+
+	cs, err := env.companyService()
+	if err != nil {
+		return err
+	}
+
+	// events are stored in the outbox, so they are published by the relay of the running app
+	replayer := services.NewEventReplayer(env.cfg.AppName, cs, repo)
+
+	for _, id := range ids {
+		replayed, err := replayer.Replay(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		env.logger.Info("replayed events", zap.Stringer("company_id", id), zap.Int("events", replayed))
+	}
+
+	return nil
+}
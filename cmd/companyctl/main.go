@@ -0,0 +1,185 @@
+// Command companyctl administers the app: it applies migrations, manages companies through the service
+// layer, mints tokens for testing and replays events. It reads the same environment as the app.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"go.uber.org/zap"
+)
+
+const usage = `Usage: companyctl <command> [flags] [arguments]
+
+Commands:
+  migrate up                     apply all pending migrations
+  migrate down [-steps n]        roll back the latest n migrations
+  migrate to <version>           migrate up or down to the version
+  company create [flags]         create a company
+  company get <id>               print the company
+  company list [flags]           print a page of companies
+  company update [flags] <id>    update fields set by flags
+  company delete [flags] <id>    delete the company
+  company restore <id>           restore the deleted company
+  company history <id>           print recorded versions of the company
+  token [flags]                  print a token signed with APP_SIGN_KEY
+  events replay <id>...          republish events of the companies
+
+Run "companyctl <command> [subcommand] -h" for the flags of the command.
+`
+
+// errUsage is returned when the command line is malformed, usage has been printed by then.
+var errUsage = errors.New("invalid usage")
+
+// command runs a single command with the arguments following its name.
+type command func(ctx context.Context, env *environment, args []string) error
+
+var commands = map[string]command{
+	"migrate": runMigrate,
+	"company": runCompany,
+	"token":   runToken,
+	"events":  runEvents,
+}
+
+// environment is shared by the commands, the repository is connected to on first use.
+type environment struct {
+	cfg    *internal.Config
+	logger *zap.Logger
+	out    io.Writer
+	repo   *repositories.Postgres
+}
+
+func (e *environment) postgres() (*repositories.Postgres, error) {
+	if e.repo != nil {
+		return e.repo, nil
+	}
+
+	if e.cfg.Storage != internal.StoragePostgres {
+		return nil, fmt.Errorf("%s storage isn't supported, companyctl works with postgres storage only", e.cfg.Storage)
+	}
+
+	repo, err := repositories.NewPostgres(
+		e.cfg.DSN,
+		e.cfg.DBMaxPoolSize,
+		e.cfg.DBConnAttempts,
+		e.cfg.DBConnTimeoutSeconds,
+		e.logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e.repo = repo
+
+	return repo, nil
+}
+
+func (e *environment) close() {
+	if e.repo != nil {
+		e.repo.Pool.Close()
+	}
+}
+
+// print writes value to the output as indented JSON.
+func (e *environment) print(value any) error {
+	encoder := json.NewEncoder(e.out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+// newFlagSet returns flag set which prints usage of the command instead of exiting on errors.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: companyctl %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseFlags parses flags of the command and checks the amount of the remaining arguments,
+// maxArgs below zero allows any amount.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errUsage
+	}
+
+	if fs.NArg() < minArgs || maxArgs >= 0 && fs.NArg() > maxArgs {
+		fs.Usage()
+
+		return errUsage
+	}
+
+	return nil
+}
+
+// usageError prints subcommands of the command and returns errUsage.
+func usageError(name, subcommands string) error {
+	fmt.Fprintf(os.Stderr, "Usage: companyctl %s <%s> [flags] [arguments]\n", name, subcommands)
+
+	return errUsage
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := internal.NewConfig()
+	if err != nil {
+		log.Printf("error loading config: %s", err)
+		return 1
+	}
+
+	logger, err := logging.New(cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Printf("error creating logger: %s", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	env := &environment{cfg: cfg, logger: logger.With(zap.String("app", cfg.AppName)), out: os.Stdout}
+	defer env.close()
+
+	err = cmd(ctx, env, args[1:])
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(os.Stderr, "companyctl: %s\n", err)
+		return 1
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlags(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name        string
+		Args        []string
+		MaxArgs     int
+		WantErr     error
+		WantVersion int
+		WantArgs    []string
+	}{
+		{
+			Name: "flags and argument", Args: []string{"-version", "2", "id"}, MaxArgs: 1,
+			WantVersion: 2, WantArgs: []string{"id"},
+		},
+		{Name: "default flags", Args: []string{"id"}, MaxArgs: 1, WantArgs: []string{"id"}},
+		{Name: "any amount", Args: []string{"first", "second"}, MaxArgs: -1, WantArgs: []string{"first", "second"}},
+		{Name: "missing argument", Args: []string{"-version", "2"}, MaxArgs: 1, WantErr: errUsage},
+		{Name: "extra argument", Args: []string{"first", "second"}, MaxArgs: 1, WantErr: errUsage},
+		{Name: "flag after argument", Args: []string{"id", "-version", "2"}, MaxArgs: 1, WantErr: errUsage},
+		{Name: "unknown flag", Args: []string{"-unknown", "id"}, MaxArgs: 1, WantErr: errUsage},
+		{Name: "invalid value", Args: []string{"-version", "two", "id"}, MaxArgs: 1, WantErr: errUsage},
+		{Name: "help", Args: []string{"-h"}, MaxArgs: 1, WantErr: flag.ErrHelp},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newFlagSet("company delete", "<id>")
+			fs.SetOutput(io.Discard)
+			version := fs.Int("version", 0, "expected version of the company")
+
+			err := parseFlags(fs, tc.Args, 1, tc.MaxArgs)
+			if tc.WantErr != nil {
+				assert.ErrorIs(t, err, tc.WantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.WantVersion, *version)
+			assert.Equal(t, tc.WantArgs, fs.Args())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+func runMigrate(_ context.Context, env *environment, args []string) error {
+	if len(args) == 0 {
+		return usageError("migrate", "up|down|to")
+	}
+
+	switch args[0] {
+	case "up":
+		if err := parseFlags(newFlagSet("migrate up", ""), args[1:], 0, 0); err != nil {
+			return err
+		}
+
+		repo, err := env.postgres()
+		if err != nil {
+			return err
+		}
+
+		return repo.Migrate()
+	case "down":
+		fs := newFlagSet("migrate down", "")
+		steps := fs.Int("steps", 1, "amount of migrations to roll back")
+
+		if err := parseFlags(fs, args[1:], 0, 0); err != nil {
+			return err
+		}
+
+		if *steps < 1 {
+			return fmt.Errorf("migrate down: steps has to be positive")
+		}
+
+		repo, err := env.postgres()
+		if err != nil {
+			return err
+		}
+
+		return repo.MigrateDown(*steps)
+	case "to":
+		fs := newFlagSet("migrate to", "<version>")
+		if err := parseFlags(fs, args[1:], 1, 1); err != nil {
+			return err
+		}
+
+		version, err := strconv.ParseUint(fs.Arg(0), 10, 0)
+		if err != nil {
+			return fmt.Errorf("migrate to: invalid version \"%s\"", fs.Arg(0))
+		}
+
+		repo, err := env.postgres()
+		if err != nil {
+			return err
+		}
+
+		return repo.MigrateTo(uint(version))
+	default:
+		return usageError("migrate", "up|down|to")
+	}
+}
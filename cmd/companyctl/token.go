@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claimFlags collects repeated -claim flags, values are parsed as JSON and kept as strings otherwise.
+type claimFlags map[string]any
+
+func (c claimFlags) String() string {
+	return ""
+}
+
+func (c claimFlags) Set(s string) error {
+	name, raw, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("claim has to be name=value")
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+
+	c[name] = value
+
+	return nil
+}
+
+func runToken(_ context.Context, env *environment, args []string) error {
+	fs := newFlagSet("token", "")
+	subject := fs.String("sub", "companyctl", "subject of the token")
+	roles := fs.String("roles", "", "comma separated roles")
+	scope := fs.String("scope", "", "space separated scopes")
+	ttl := fs.Duration("ttl", time.Hour, "lifetime of the token, 0 issues token without expiration")
+
+	claims := make(claimFlags)
+	fs.Var(claims, "claim", "additional claim as name=value, repeatable, JSON values are decoded")
+
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if env.cfg.AppSignKey == "" {
+		return errors.New("token: APP_SIGN_KEY isn't set")
+	}
+
+	now := time.Now()
+	mapClaims := jwt.MapClaims{"sub": *subject, "iat": now.Unix()}
+
+	if *roles != "" {
+		mapClaims["roles"] = strings.Split(*roles, ",")
+	}
+
+	if *scope != "" {
+		mapClaims["scope"] = *scope
+	}
+
+	if *ttl > 0 {
+		mapClaims["exp"] = now.Add(*ttl).Unix()
+	}
+
+	if env.cfg.AuthIssuer != "" {
+		mapClaims["iss"] = env.cfg.AuthIssuer
+	}
+
+	if env.cfg.AuthAudience != "" {
+		mapClaims["aud"] = env.cfg.AuthAudience
+	}
+
+	for name, value := range claims {
+		mapClaims[name] = value
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims).SignedString([]byte(env.cfg.AppSignKey))
+	if err != nil {
+		return fmt.Errorf("token: error signing token: %w", err)
+	}
+
+	_, err = fmt.Fprintln(env.out, token)
+
+	return err
+}
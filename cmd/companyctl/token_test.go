@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimFlags(t *testing.T) {
+	t.Parallel()
+
+	claims := make(claimFlags)
+
+	for _, value := range []string{
+		"tenant=acme",
+		"admin=true",
+		"level=3",
+		`groups=["a","b"]`,
+		"empty=",
+		"url=https://example.com/?a=b",
+	} {
+		require.NoError(t, claims.Set(value), value)
+	}
+
+	assert.Equal(t, claimFlags{
+		"tenant": "acme",
+		"admin":  true,
+		"level":  float64(3),
+		"groups": []any{"a", "b"},
+		"empty":  "",
+		"url":    "https://example.com/?a=b",
+	}, claims)
+
+	assert.Error(t, claims.Set("tenant"))
+	assert.Error(t, claims.Set("=acme"))
+}
+
+func TestRunToken(t *testing.T) {
+	t.Parallel()
+
+	const signKey = "sign key"
+
+	out := new(bytes.Buffer)
+	env := &environment{
+		cfg: &internal.Config{AppSignKey: signKey, AuthIssuer: "issuer", AuthAudience: "audience"},
+		out: out,
+	}
+
+	err := runToken(context.Background(), env, []string{
+		"-sub", "user", "-roles", "editor,viewer", "-claim", "tenant=acme", "-claim", "sub=overridden", "-ttl", "0",
+	})
+	require.NoError(t, err)
+
+	claims := make(jwt.MapClaims)
+	_, err = jwt.ParseWithClaims(strings.TrimSpace(out.String()), claims, func(*jwt.Token) (any, error) {
+		return []byte(signKey), nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "overridden", claims["sub"], "-claim has to override the other flags")
+	assert.Equal(t, []any{"editor", "viewer"}, claims["roles"])
+	assert.Equal(t, "acme", claims["tenant"])
+	assert.Equal(t, "issuer", claims["iss"])
+	assert.Equal(t, "audience", claims["aud"])
+	assert.NotContains(t, claims, "exp", "zero ttl issues tokens without expiration")
+
+	env.cfg.AppSignKey = ""
+	assert.Error(t, runToken(context.Background(), env, nil))
+}
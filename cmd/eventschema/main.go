@@ -0,0 +1,70 @@
+// Command eventschema writes JSON Schema documents of the current schema versions of the company mutation
+// events. Documents of the published versions are only rewritten when the change doesn't break consumers,
+// otherwise the schema version of the event has to be incremented, so the new document is written alongside.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/eventschema"
+)
+
+func main() {
+	dir := flag.String("dir", "api/events", "directory the documents are written to")
+	flag.Parse()
+
+	if err := run(*dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(dir string) error {
+	for _, kind := range ports.EventKinds() {
+		schema, err := eventschema.Generate(kind)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, eventschema.FileName(kind))
+
+		published, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("read %s: %w", path, err)
+		default:
+			publishedSchema, err := eventschema.Parse(published)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", path, err)
+			}
+
+			if changes := eventschema.BreakingChanges(publishedSchema, schema); len(changes) > 0 {
+				return fmt.Errorf(
+					"%s payload breaks consumers of schema version %d, increment the version: %s",
+					kind.Name,
+					kind.SchemaVersion,
+					strings.Join(changes, "; "),
+				)
+			}
+		}
+
+		document, err := eventschema.Document(schema)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, document, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -1,48 +1,327 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
+	"go.uber.org/zap"
 )
 
-func run() error {
-	cfg, err := internal.NewConfig()
+// server is a transport serving the API until it's shut down.
+type server interface {
+	Run() error
+	Shutdown(ctx context.Context) error
+}
+
+// run starts the app and blocks until it receives SIGINT or SIGTERM or one of the transports fails.
+// On shutdown transports stop accepting requests and drain in-flight ones, then background jobs
+// are stopped, pending events are published and Kafka writer is flushed, and the pool is closed last.
+func run(cfg *internal.Config, logger *zap.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(
+		ctx,
+		cfg.TracingExporter,
+		cfg.TracingOTLPEndpoint,
+		cfg.AppName,
+		cfg.AppVersion,
+		cfg.TracingSampleRatio,
+	)
 	if err != nil {
 		return err
 	}
 
-	repo, err := repositories.NewPostgres(cfg.DSN, cfg.DBMaxPoolSize, cfg.DBConnAttempts, cfg.DBConnTimeoutSeconds)
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("shutdown tracing", zap.Error(err))
+		}
+	}()
+
+	prometheus := metrics.NewPrometheus()
+
+	repo, repoChecks, closeRepo, err := openStorage(cfg, prometheus, logger)
 	if err != nil {
 		return err
 	}
-	defer repo.Pool.Close()
+	defer closeRepo()
 
-	if cfg.DBApplyMigrations == 1 {
-		if err := repo.Migrate(); err != nil {
+	writer, writerCheck, err := openEventsSink(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := writer.Close(); err != nil {
+			logger.Error("close events writer", zap.Error(err))
+		}
+	}()
+
+	instrumentedRepo := metrics.NewRepository(repo, prometheus)
+	eventsWriter := metrics.NewEventsWriter(writer, prometheus)
+
+	// background jobs are stopped separately from the app, after the transports are drained
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	var jobs sync.WaitGroup
+
+	runJob := func(job func(ctx context.Context)) {
+		jobs.Add(1)
+
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+
+	relay := services.NewOutboxRelay(
+		instrumentedRepo,
+		eventsWriter,
+		time.Duration(cfg.OutboxPollIntervalMs)*time.Millisecond,
+		cfg.OutboxBatchSize,
+		time.Duration(cfg.OutboxMaxBackoffSeconds)*time.Second,
+		logger,
+	)
+	runJob(relay.Run)
+
+	if cfg.PurgeRetentionHours > 0 {
+		purgeJob := services.NewPurgeJob(
+			cfg.AppName,
+			instrumentedRepo,
+			time.Duration(cfg.PurgeRetentionHours)*time.Hour,
+			time.Duration(cfg.PurgeIntervalMinutes)*time.Minute,
+			logger,
+		)
+		runJob(purgeJob.Run)
+	}
+
+	var keySet *auth.KeySet
+	if cfg.AuthJWKSSource != "" {
+		keySet, err = auth.NewKeySet(
+			cfg.AuthJWKSSource,
+			time.Duration(cfg.AuthJWKSRefreshSeconds)*time.Second,
+			logger,
+		)
+		if err != nil {
 			return err
 		}
+
+		runJob(keySet.Run)
 	}
 
-	kafka, err := events.NewKafkaWriter(cfg.KafkaBrokers, cfg.KafkaTopic)
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{
+		HMACKey:    cfg.AppSignKey,
+		KeySet:     keySet,
+		Issuer:     cfg.AuthIssuer,
+		Audience:   cfg.AuthAudience,
+		Leeway:     time.Duration(cfg.AuthLeewaySeconds) * time.Second,
+		RequireExp: cfg.AuthRequireExp,
+	})
 	if err != nil {
 		return err
 	}
-	defer kafka.Close()
 
-	companyService := services.NewCompanyService(cfg.AppName, repo, kafka)
+	companyService := services.NewCompanyService(cfg.AppName, instrumentedRepo, prometheus, logger)
+
+	idempotencyCleaner := services.NewIdempotencyCleaner(repo, time.Hour, logger)
+	runJob(idempotencyCleaner.Run)
+
+	if len(cfg.AppTransports) == 0 {
+		return fmt.Errorf("no transports configured")
+	}
+
+	var httpMetrics *metrics.Prometheus
+	if cfg.MetricsEnabled {
+		httpMetrics = prometheus
+	}
+
+	healthChecks := append(repoChecks, writerCheck)
+
+	servers := make([]server, 0, len(cfg.AppTransports))
+
+	for _, transport := range cfg.AppTransports {
+		switch transport {
+		case "http":
+			servers = append(servers, handlers.NewHTTPHandler(
+				handlers.HTTPConfig{
+					Port: cfg.AppPort,
+					Mode: cfg.AppMode,
+					Timeouts: handlers.ServerTimeouts{
+						Read:  time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+						Write: time.Duration(cfg.HTTPWriteTimeoutSeconds) * time.Second,
+						Idle:  time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
+					},
+					RequireIfMatch:   cfg.HTTPRequireIfMatch,
+					IdempotencyStore: repo,
+					IdempotencyTTL:   time.Duration(cfg.HTTPIdempotencyTTLHours) * time.Hour,
+					Metrics:          httpMetrics,
+					HealthChecks:     healthChecks,
+				},
+				verifier,
+				logger,
+				companyService,
+			))
+		case "grpc":
+			servers = append(servers, handlers.NewGRPCHandler(cfg.GRPCPort, verifier, logger, companyService))
+		default:
+			return fmt.Errorf("unsupported transport \"%s\"", transport)
+		}
+	}
+
+	// every transport runs until it's shut down, so an error of any of them stops the app
+	errs := make(chan error, len(servers))
+
+	for _, s := range servers {
+		s := s
+		go func() {
+			if err := s.Run(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	case err = <-errs:
+		logger.Error("transport failed, shutting down", zap.Error(err))
+	}
 
-	handler := handlers.NewHTTPHandler(cfg.AppPort, cfg.AppMode, cfg.AppSignKey, companyService)
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(cfg.ShutdownGracePeriodSeconds)*time.Second,
+	)
+	defer cancel()
 
-	return handler.Run()
+	var wg sync.WaitGroup
+
+	for _, s := range servers {
+		s := s
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				logger.Error("shutdown transport", zap.Error(err))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	stopJobs()
+	jobs.Wait()
+
+	if err := relay.Drain(shutdownCtx); err != nil {
+		logger.Error("drain outbox", zap.Error(err))
+	}
+
+	logger.Info("shutdown completed")
+
+	return err
+}
+
+// storage is a repository which also keeps idempotency keys.
+type storage interface {
+	ports.Repository
+	ports.IdempotencyStore
+}
+
+// openStorage returns the configured storage together with its health checks and a function releasing it.
+func openStorage(
+	cfg *internal.Config,
+	prometheus *metrics.Prometheus,
+	logger *zap.Logger,
+) (storage, []ports.HealthCheck, func(), error) {
+	if cfg.Storage == internal.StorageMemory {
+		logger.Warn("using in-memory storage, data is lost once the app stops")
+
+		memory := repositories.NewMemory(logger)
+
+		return memory, []ports.HealthCheck{{Name: "memory", Required: true, Check: memory.Ping}}, func() {}, nil
+	}
+
+	repo, err := repositories.NewPostgres(
+		cfg.DSN,
+		cfg.DBMaxPoolSize,
+		cfg.DBConnAttempts,
+		cfg.DBConnTimeoutSeconds,
+		logger,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if cfg.DBApplyMigrations == 1 {
+		if err := repo.Migrate(); err != nil {
+			repo.Pool.Close()
+
+			return nil, nil, nil, err
+		}
+	}
+
+	if err := prometheus.Register(metrics.NewPoolCollector(repo.Pool)); err != nil {
+		repo.Pool.Close()
+
+		return nil, nil, nil, err
+	}
+
+	checks := []ports.HealthCheck{
+		{Name: "postgres", Required: true, Check: repo.Ping},
+		{Name: "migrations", Required: true, Check: repo.CheckMigrations},
+	}
+
+	return repo, checks, repo.Pool.Close, nil
+}
+
+// openEventsSink returns the configured events writer together with its health check.
+func openEventsSink(cfg *internal.Config) (ports.EventsWriter, ports.HealthCheck, error) {
+	if cfg.EventsSink == internal.EventsSinkMemory {
+		memory := events.NewMemoryWriter(cfg.EventsMemoryLimit)
+
+		return memory, ports.HealthCheck{Name: "events", Required: true, Check: memory.Ping}, nil
+	}
+
+	kafka, err := events.NewKafkaWriter(cfg.KafkaBrokers, cfg.KafkaTopic, events.Format(cfg.KafkaFormat))
+	if err != nil {
+		return nil, ports.HealthCheck{}, err
+	}
+
+	return kafka, ports.HealthCheck{Name: "kafka", Required: cfg.KafkaRequired, Check: kafka.Ping}, nil
 }
 
 func main() {
-	if err := run(); err != nil {
-		log.Fatalf("error runnning the app: %s", err)
+	cfg, err := internal.NewConfig()
+	if err != nil {
+		log.Fatalf("error loading config: %s", err)
+	}
+
+	logger, err := logging.New(cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("error creating logger: %s", err)
+	}
+
+	logger = logger.With(zap.String("app", cfg.AppName), zap.String("version", cfg.AppVersion))
+
+	if err := run(cfg, logger); err != nil {
+		logger.Fatal("error running the app", zap.Error(err))
 	}
 }
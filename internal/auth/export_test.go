@@ -0,0 +1,11 @@
+package auth
+
+import "time"
+
+// SetMinRefreshInterval overrides the interval limiting early refreshes of the keys.
+func (ks *KeySet) SetMinRefreshInterval(interval time.Duration) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.minRefreshInterval = interval
+}
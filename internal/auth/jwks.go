@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	jwksFetchTimeout       = 10 * time.Second
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySet is a cached set of public keys loaded from a JWKS document,
+// which is either a local file or served by the token issuer.
+type KeySet struct {
+	source          string
+	refreshInterval time.Duration
+	client          *http.Client
+	logger          *zap.Logger
+
+	// minRefreshInterval limits refreshes caused by tokens signed with unknown keys
+	minRefreshInterval time.Duration
+	refreshes          singleflight.Group
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+	// attemptedAt is the time of the last refresh, including the failed ones,
+	// so unavailable source isn't fetched by every token signed with an unknown key
+	attemptedAt time.Time
+}
+
+// NewKeySet loads keys from the source, which is treated as URL if it starts with http:// or https://
+// and as a file path otherwise.
+func NewKeySet(source string, refreshInterval time.Duration, logger *zap.Logger) (*KeySet, error) {
+	ks := &KeySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		logger:          logger.Named("jwks"),
+
+		minRefreshInterval: jwksMinRefreshInterval,
+	}
+
+	if err := ks.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Run refreshes the keys every refresh interval until ctx is done,
+// previously loaded keys are kept if refreshing fails.
+func (ks *KeySet) Run(ctx context.Context) {
+	ticker := time.NewTicker(ks.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Refresh(ctx); err != nil {
+				ks.logger.Error("refresh keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Refresh reloads the keys from the source.
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	ks.mu.Lock()
+	ks.attemptedAt = time.Now()
+	ks.mu.Unlock()
+
+	data, err := ks.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching jwks from %s: %w", ks.source, err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("error parsing jwks from %s: %w", ks.source, err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// Key returns the key with the given id. Unknown key ids cause an early refresh,
+// so keys added by the issuer during rotation are picked up before the next scheduled refresh.
+// Concurrent requests share a single refresh.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if !ks.stale() {
+		return nil, ErrUnknownKey
+	}
+
+	refreshed := ks.refreshes.DoChan("", func() (any, error) {
+		// requests which found the keys stale might wait until the previous refresh finishes
+		if !ks.stale() {
+			return nil, nil
+		}
+
+		// the refresh is shared, so it isn't canceled together with the request which started it
+		return nil, ks.Refresh(context.Background())
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-refreshed:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+	}
+
+	ks.mu.RLock()
+	key, ok = ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+// stale reports whether the keys haven't been refreshed for long enough to refresh them early.
+func (ks *KeySet) stale() bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return time.Since(ks.attemptedAt) >= ks.minRefreshInterval
+}
+
+func (ks *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return os.ReadFile(ks.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// jwk holds the subset of RFC 7517 JSON Web Key parameters used for signature verification.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses signing keys of JWKS document by their ids, keys of unsupported types and curves are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key \"%s\": %w", k.Kid, err)
+		}
+
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// publicKey returns nil key for unsupported key types and curves.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Scopes granting access to protected operations. ScopeAdmin grants every other scope.
+const (
+	ScopeWrite  = "companies:write"
+	ScopeDelete = "companies:delete"
+	ScopeAudit  = "companies:audit"
+	ScopeAdmin  = "companies:admin"
+)
+
+// roleScopes maps roles from the "roles" claim to the scopes they grant.
+var roleScopes = map[string][]string{
+	"viewer":  {},
+	"editor":  {ScopeWrite},
+	"manager": {ScopeWrite, ScopeDelete},
+	"auditor": {ScopeAudit},
+	"admin":   {ScopeAdmin},
+}
+
+// Principal is the authenticated caller described by the token claims.
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scopes  []string
+}
+
+// NewPrincipal parses the principal from token claims. Scopes are read from either space-delimited
+// "scope" claim or "scp" list, roles are read from "roles" list, unknown roles are ignored.
+func NewPrincipal(claims jwt.MapClaims) *Principal {
+	subject, _ := claims.GetSubject()
+
+	principal := &Principal{
+		Subject: subject,
+		Roles:   claimStrings(claims["roles"]),
+		Scopes:  claimStrings(claims["scp"]),
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = append(principal.Scopes, strings.Fields(scope)...)
+	}
+
+	return principal
+}
+
+// HasScope reports whether the principal was granted the scope either directly or by one of its roles.
+func (p *Principal) HasScope(scope string) bool {
+	for _, granted := range p.grantedScopes() {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Principal) grantedScopes() []string {
+	scopes := append([]string{}, p.Scopes...)
+	for _, role := range p.Roles {
+		scopes = append(scopes, roleScopes[role]...)
+	}
+
+	return scopes
+}
+
+// claimStrings converts either a single string or a list of strings claim value to a slice.
+func claimStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
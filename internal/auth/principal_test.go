@@ -0,0 +1,59 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipalHasScope(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Claims   jwt.MapClaims
+		Scope    string
+		HasScope bool
+	}{
+		{
+			jwt.MapClaims{"sub": "user"},
+			auth.ScopeWrite,
+			false,
+		},
+		{
+			jwt.MapClaims{"scope": "companies:write companies:delete"},
+			auth.ScopeDelete,
+			true,
+		},
+		{
+			jwt.MapClaims{"scp": []any{"companies:write"}},
+			auth.ScopeDelete,
+			false,
+		},
+		{
+			jwt.MapClaims{"roles": []any{"editor"}},
+			auth.ScopeWrite,
+			true,
+		},
+		{
+			jwt.MapClaims{"roles": []any{"editor", "unknown"}},
+			auth.ScopeDelete,
+			false,
+		},
+		{
+			jwt.MapClaims{"roles": "admin"},
+			auth.ScopeAudit,
+			true,
+		},
+		{
+			jwt.MapClaims{"scope": auth.ScopeAdmin},
+			auth.ScopeDelete,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.HasScope, auth.NewPrincipal(tc.Claims).HasScope(tc.Scope), tc.Claims)
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	hmacMethods       = []string{"HS256", "HS384", "HS512"}
+	asymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// VerifierConfig describes accepted tokens. HMACKey and KeySet enable symmetric and asymmetric
+// signatures respectively, at least one of them has to be set. Empty Issuer and Audience aren't checked.
+type VerifierConfig struct {
+	HMACKey    string
+	KeySet     *KeySet
+	Issuer     string
+	Audience   string
+	Leeway     time.Duration
+	RequireExp bool
+}
+
+// Verifier validates bearer tokens and parses principals from their claims.
+type Verifier struct {
+	hmacKey    []byte
+	keySet     *KeySet
+	requireExp bool
+	parser     *jwt.Parser
+}
+
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	var methods []string
+	if cfg.HMACKey != "" {
+		methods = append(methods, hmacMethods...)
+	}
+
+	if cfg.KeySet != nil {
+		methods = append(methods, asymmetricMethods...)
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("new verifier: either HMAC key or JWKS has to be configured")
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(cfg.Leeway)}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Verifier{
+		hmacKey:    []byte(cfg.HMACKey),
+		keySet:     cfg.KeySet,
+		requireExp: cfg.RequireExp,
+		parser:     jwt.NewParser(options...),
+	}, nil
+}
+
+// Verify checks the token signature and registered claims and returns the principal it describes.
+// HMAC signed tokens are verified with the shared key, the rest are verified with the JWKS key
+// referenced by the "kid" header.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return v.hmacKey, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		return v.keySet.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if v.requireExp {
+		if exp, _ := claims.GetExpirationTime(); exp == nil {
+			return nil, fmt.Errorf("token is missing exp claim")
+		}
+	}
+
+	return NewPrincipal(claims), nil
+}
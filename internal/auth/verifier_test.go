@@ -0,0 +1,289 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const hmacKey = "test-key"
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func toJWK(kid string, key crypto.PublicKey) map[string]string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(k.X.Bytes()), "y": b64(k.Y.Bytes())}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(k)}
+	default:
+		panic("unsupported key")
+	}
+}
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return testKeys{rsa: rsaKey, ecdsa: ecdsaKey, ed25519: ed25519Key}
+}
+
+func (k testKeys) jwks() []byte {
+	data, _ := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			toJWK("rsa", &k.rsa.PublicKey),
+			toJWK("ec", &k.ecdsa.PublicKey),
+			toJWK("ed", k.ed25519.Public()),
+		},
+	})
+
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	keys, otherKeys := newTestKeys(t), newTestKeys(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, keys.jwks(), 0o600))
+
+	keySet, err := auth.NewKeySet(jwksPath, time.Minute, zap.NewNop())
+	require.NoError(t, err)
+
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{
+		HMACKey:  hmacKey,
+		KeySet:   keySet,
+		Issuer:   "issuer",
+		Audience: "app",
+	})
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := jwt.MapClaims{"sub": "user", "iss": "issuer", "aud": "app", "exp": exp, "roles": []string{"editor"}}
+
+	testCases := []struct {
+		Name    string
+		Token   string
+		IsValid bool
+	}{
+		{"HS256", sign(t, jwt.SigningMethodHS256, "", []byte(hmacKey), claims), true},
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, claims), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec", keys.ecdsa, claims), true},
+		{"EdDSA", sign(t, jwt.SigningMethodEdDSA, "ed", keys.ed25519, claims), true},
+		{"wrong HMAC key", sign(t, jwt.SigningMethodHS256, "", []byte("other"), claims), false},
+		{"wrong key", sign(t, jwt.SigningMethodRS256, "rsa", otherKeys.rsa, claims), false},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, "other", keys.rsa, claims), false},
+		{"mismatched kid", sign(t, jwt.SigningMethodES256, "rsa", keys.ecdsa, claims), false},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, jwt.MapClaims{"iss": "other", "aud": "app"}), false},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, jwt.MapClaims{"iss": "issuer", "aud": "other"}), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, jwt.MapClaims{
+			"iss": "issuer", "aud": "app", "exp": time.Now().Add(-time.Minute).Unix(),
+		}), false},
+		{"not valid yet", sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, jwt.MapClaims{
+			"iss": "issuer", "aud": "app", "nbf": time.Now().Add(time.Minute).Unix(),
+		}), false},
+	}
+
+	for _, tc := range testCases {
+		principal, err := verifier.Verify(context.Background(), tc.Token)
+		if tc.IsValid {
+			assert.NoError(t, err, tc.Name)
+			assert.Equal(t, "user", principal.Subject, tc.Name)
+			assert.True(t, principal.HasScope(auth.ScopeWrite), tc.Name)
+		} else {
+			assert.Error(t, err, tc.Name)
+		}
+	}
+}
+
+func TestVerifierRequireExp(t *testing.T) {
+	t.Parallel()
+
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{HMACKey: hmacKey, RequireExp: true})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", []byte(hmacKey), jwt.MapClaims{}))
+	assert.Error(t, err)
+
+	token := sign(t, jwt.SigningMethodHS256, "", []byte(hmacKey), jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})
+	_, err = verifier.Verify(context.Background(), token)
+	assert.NoError(t, err)
+
+	// asymmetric tokens are rejected unless JWKS is configured
+	keys := newTestKeys(t)
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, jwt.MapClaims{}))
+	assert.Error(t, err)
+
+	_, err = auth.NewVerifier(auth.VerifierConfig{})
+	assert.Error(t, err)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		jwks = newTestKeys(t).jwks()
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, _ = w.Write(jwks)
+	}))
+	defer server.Close()
+
+	keySet, err := auth.NewKeySet(server.URL, time.Minute, zap.NewNop())
+	require.NoError(t, err)
+
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{KeySet: keySet})
+	require.NoError(t, err)
+
+	rotated := newTestKeys(t)
+	token := sign(t, jwt.SigningMethodRS256, "rsa", rotated.rsa, jwt.MapClaims{"sub": "user"})
+
+	_, err = verifier.Verify(context.Background(), token)
+	assert.Error(t, err)
+
+	mu.Lock()
+	jwks = rotated.jwks()
+	mu.Unlock()
+
+	require.NoError(t, keySet.Refresh(context.Background()))
+
+	_, err = verifier.Verify(context.Background(), token)
+	assert.NoError(t, err)
+}
+
+func TestKeySetRefreshRateLimit(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	jwks := newTestKeys(t).jwks()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			_, _ = w.Write(jwks)
+			return
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	keySet, err := auth.NewKeySet(server.URL, time.Minute, zap.NewNop())
+	require.NoError(t, err)
+
+	keySet.SetMinRefreshInterval(200 * time.Millisecond)
+	time.Sleep(250 * time.Millisecond)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := keySet.Key(context.Background(), "unknown")
+			assert.Error(t, err)
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(2), requests.Load(), "concurrent requests have to share a single refresh")
+
+	// failed refreshes limit the next ones the same way successful do
+	_, err = keySet.Key(context.Background(), "unknown")
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestKeySetUnsupportedCurves(t *testing.T) {
+	t.Parallel()
+
+	keys := newTestKeys(t)
+
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+
+	require.NoError(t, json.Unmarshal(keys.jwks(), &jwks))
+
+	secp256k1 := toJWK("secp256k1", &keys.ecdsa.PublicKey)
+	secp256k1["crv"] = "secp256k1"
+	x25519 := toJWK("x25519", keys.ed25519.Public())
+	x25519["crv"] = "X25519"
+	jwks.Keys = append(jwks.Keys, secp256k1, x25519)
+
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, data, 0o600))
+
+	keySet, err := auth.NewKeySet(jwksPath, time.Minute, zap.NewNop())
+	require.NoError(t, err, "keys of unsupported curves have to be skipped")
+
+	_, err = keySet.Key(context.Background(), "ec")
+	assert.NoError(t, err)
+
+	_, err = keySet.Key(context.Background(), "x25519")
+	assert.ErrorIs(t, err, auth.ErrUnknownKey)
+}
@@ -1,7 +1,19 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/caarlos0/env/v6"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+)
+
+// Supported values of Config.Storage and Config.EventsSink, Config.KafkaFormat is one of events.Format values.
+const (
+	StoragePostgres  = "postgres"
+	StorageMemory    = "memory"
+	EventsSinkKafka  = "kafka"
+	EventsSinkMemory = "memory"
 )
 
 // Config represents applications config loaded from environment variables with default values.
@@ -10,16 +22,79 @@ type Config struct {
 	AppPort    string `env:"APP_PORT" envDefault:"8080"`
 	AppVersion string `env:"APP_VERSION,required"`
 	AppMode    string `env:"APP_MODE" envDefault:"debug"`
-	AppSignKey string `env:"APP_SIGN_KEY,required"`
+	AppSignKey string `env:"APP_SIGN_KEY"`
+
+	// LogLevel is one of "debug", "info", "warn" and "error", LogFormat is either "json" or "console".
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info"`
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json"`
+
+	// AppTransports lists APIs to serve, supported values are "http" and "grpc".
+	AppTransports []string `env:"APP_TRANSPORTS" envDefault:"http"`
+	GRPCPort      string   `env:"GRPC_PORT" envDefault:"9090"`
+
+	// AuthJWKSSource is either URL or path to the JWKS document with keys used to verify asymmetric
+	// token signatures. At least one of AuthJWKSSource and AppSignKey has to be set.
+	AuthJWKSSource         string `env:"AUTH_JWKS_SOURCE"`
+	AuthJWKSRefreshSeconds int    `env:"AUTH_JWKS_REFRESH_SECONDS" envDefault:"300"`
+	AuthIssuer             string `env:"AUTH_ISSUER"`
+	AuthAudience           string `env:"AUTH_AUDIENCE"`
+	AuthLeewaySeconds      int    `env:"AUTH_LEEWAY_SECONDS" envDefault:"0"`
+	AuthRequireExp         bool   `env:"AUTH_REQUIRE_EXP" envDefault:"false"`
+
+	// HTTP*TimeoutSeconds limit durations of reading requests, writing responses and keeping
+	// idle connections open. ShutdownGracePeriodSeconds is how long the app waits on shutdown for
+	// in-flight requests to complete and pending events to be published.
+	HTTPReadTimeoutSeconds     int `env:"HTTP_READ_TIMEOUT_SECONDS" envDefault:"10"`
+	HTTPWriteTimeoutSeconds    int `env:"HTTP_WRITE_TIMEOUT_SECONDS" envDefault:"30"`
+	HTTPIdleTimeoutSeconds     int `env:"HTTP_IDLE_TIMEOUT_SECONDS" envDefault:"120"`
+	ShutdownGracePeriodSeconds int `env:"SHUTDOWN_GRACE_PERIOD_SECONDS" envDefault:"30"`
+
+	// HTTPRequireIfMatch makes If-Match header mandatory for PATCH and DELETE requests,
+	// otherwise it is checked only when provided.
+	HTTPRequireIfMatch bool `env:"HTTP_REQUIRE_IF_MATCH" envDefault:"false"`
+
+	// HTTPIdempotencyTTLHours is how long responses to requests with Idempotency-Key header are kept.
+	HTTPIdempotencyTTLHours int `env:"HTTP_IDEMPOTENCY_TTL_HOURS" envDefault:"24"`
+
+	// MetricsEnabled exposes Prometheus metrics at /metrics endpoint of the HTTP API.
+	MetricsEnabled bool `env:"METRICS_ENABLED" envDefault:"true"`
 
-	DSN                  string `env:"DB_DSN,required"`
+	// TracingExporter is one of "none", "stdout" and "otlp". OTLP spans are sent over gRPC
+	// to TracingOTLPEndpoint, TracingSampleRatio is the share of traces started by the app to be recorded.
+	TracingExporter     string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+
+	// Storage is either "postgres" or "memory", EventsSink is either "kafka" or "memory". In-memory
+	// adapters let the app start without external services, their data is lost once the app stops.
+	// EventsMemoryLimit is the amount of the latest events kept by the in-memory sink, 0 keeps all of them.
+	Storage           string `env:"STORAGE" envDefault:"postgres"`
+	EventsSink        string `env:"EVENTS_SINK" envDefault:"kafka"`
+	EventsMemoryLimit int    `env:"EVENTS_MEMORY_LIMIT" envDefault:"1000"`
+
+	// DSN is required by postgres storage, KafkaBrokers and KafkaTopic are required by kafka sink.
+	DSN                  string `env:"DB_DSN"`
 	DBApplyMigrations    int    `env:"DB_APPLY_MIGRATIONS" envDefault:"1"`
 	DBMaxPoolSize        int    `env:"DB_MAX_POOL_SIZE" envDefault:"1"`
 	DBConnAttempts       int    `env:"DB_CONN_ATTEMPTS" envDefault:"10"`
 	DBConnTimeoutSeconds int    `env:"DB_CONN_TIMEOUT_SECONDS" envDefault:"1"`
 
-	KafkaBrokers []string `env:"KAFKA_BROKERS,required"`
-	KafkaTopic   string   `env:"KAFKA_TOPIC,required"`
+	KafkaBrokers []string `env:"KAFKA_BROKERS"`
+	KafkaTopic   string   `env:"KAFKA_TOPIC"`
+	// KafkaRequired makes the app not ready while Kafka is down, otherwise events are kept
+	// in the outbox until it's back and the readiness probe only reports it.
+	KafkaRequired bool `env:"KAFKA_REQUIRED" envDefault:"true"`
+	// KafkaFormat is either "native", which publishes events in their own envelope, or one of
+	// "cloudevents-structured" and "cloudevents-binary", which publish them as CloudEvents.
+	KafkaFormat string `env:"KAFKA_FORMAT" envDefault:"native"`
+
+	OutboxPollIntervalMs    int `env:"OUTBOX_POLL_INTERVAL_MS" envDefault:"500"`
+	OutboxBatchSize         int `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+	OutboxMaxBackoffSeconds int `env:"OUTBOX_MAX_BACKOFF_SECONDS" envDefault:"60"`
+
+	// PurgeRetentionHours is how long deleted companies are kept before being purged, 0 disables purging.
+	PurgeRetentionHours  int `env:"PURGE_RETENTION_HOURS" envDefault:"720"`
+	PurgeIntervalMinutes int `env:"PURGE_INTERVAL_MINUTES" envDefault:"60"`
 }
 
 // NewConfig returns app configuration of type Config.
@@ -31,5 +106,29 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	switch cfg.Storage {
+	case StoragePostgres:
+		if cfg.DSN == "" {
+			return nil, errors.New(`env: DB_DSN is required by "postgres" storage`)
+		}
+	case StorageMemory:
+	default:
+		return nil, fmt.Errorf("env: unsupported storage \"%s\"", cfg.Storage)
+	}
+
+	switch cfg.EventsSink {
+	case EventsSinkKafka:
+		if len(cfg.KafkaBrokers) == 0 || cfg.KafkaTopic == "" {
+			return nil, errors.New(`env: KAFKA_BROKERS and KAFKA_TOPIC are required by "kafka" events sink`)
+		}
+	case EventsSinkMemory:
+	default:
+		return nil, fmt.Errorf("env: unsupported events sink \"%s\"", cfg.EventsSink)
+	}
+
+	if err := events.Format(cfg.KafkaFormat).Validate(); err != nil {
+		return nil, fmt.Errorf("env: %w", err)
+	}
+
 	return &cfg, nil
 }
@@ -14,15 +14,8 @@ const (
 	employeeCntMax    = 10_000_000_000
 )
 
-var (
-	availableTypes = map[string]any{
-		"Corporations":        struct{}{},
-		"NonProfit":           struct{}{},
-		"Cooperative":         struct{}{},
-		"Sole Proprietorship": struct{}{},
-	}
-	availableTypesString string
-)
+// companyTypes lists allowed company types.
+var companyTypes = []string{"Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"}
 
 type Company struct {
 	ID          uuid.UUID `json:"id" binding:"omitempty"`
@@ -31,45 +24,34 @@ type Company struct {
 	EmployeeCnt int       `json:"employee_cnt"`
 	Registered  bool      `json:"registered"`
 	Type        string    `json:"type"`
+	Version     int       `json:"version"`
 }
 
 func (c *Company) SetID() {
 	c.ID = uuid.New()
 }
 
+// Validate checks all fields of the company and returns ValidationErrors listing every violation.
 func (c *Company) Validate() error {
-	var errorMsgs []string
-
-	if err := ValidateName(c.Name); err != nil {
-		errorMsgs = append(errorMsgs, err.Error())
-	}
-
-	if err := ValidateDescription(c.Description); err != nil {
-		errorMsgs = append(errorMsgs, err.Error())
-	}
+	var errs ValidationErrors
 
-	if err := ValidateType(c.Type); err != nil {
-		errorMsgs = append(errorMsgs, err.Error())
-	}
-
-	if err := ValidateEmployeeCnt(c.EmployeeCnt); err != nil {
-		errorMsgs = append(errorMsgs, err.Error())
-	}
-
-	if len(errorMsgs) > 0 {
-		return fmt.Errorf("%s", strings.Join(errorMsgs, "; "))
-	}
+	errs = errs.Append(ValidateName(c.Name))
+	errs = errs.Append(ValidateDescription(c.Description))
+	errs = errs.Append(ValidateType(c.Type))
+	errs = errs.Append(ValidateEmployeeCnt(c.EmployeeCnt))
 
-	return nil
+	return errs.Err()
 }
 
 func ValidateEmployeeCnt(cnt int) error {
 	if cnt < 0 {
-		return fmt.Errorf("amount of employee can't be negative")
+		return NewValidationError("employee_cnt", RuleMin, "amount of employee can't be negative", 0)
 	}
 
 	if cnt > employeeCntMax {
-		return fmt.Errorf("amount of employee can't exceed %d", employeeCntMax)
+		return NewValidationError(
+			"employee_cnt", RuleMax, fmt.Sprintf("amount of employee can't exceed %d", employeeCntMax), employeeCntMax,
+		)
 	}
 
 	return nil
@@ -77,11 +59,15 @@ func ValidateEmployeeCnt(cnt int) error {
 
 func ValidateName(companyName string) error {
 	if len(companyName) < nameMinLen {
-		return fmt.Errorf("company name is too short, minimal length is %d", nameMinLen)
+		return NewValidationError(
+			"name", RuleMinLength, fmt.Sprintf("company name is too short, minimal length is %d", nameMinLen), nameMinLen,
+		)
 	}
 
 	if len(companyName) > nameMaxLen {
-		return fmt.Errorf("company name is too long, max length is %d", nameMaxLen)
+		return NewValidationError(
+			"name", RuleMaxLength, fmt.Sprintf("company name is too long, max length is %d", nameMaxLen), nameMaxLen,
+		)
 	}
 
 	return nil
@@ -89,30 +75,32 @@ func ValidateName(companyName string) error {
 
 func ValidateDescription(companyDescription string) error {
 	if len(companyDescription) > descriptionMaxLen {
-		return fmt.Errorf("company description is too long, max length is %d", descriptionMaxLen)
+		return NewValidationError(
+			"description",
+			RuleMaxLength,
+			fmt.Sprintf("company description is too long, max length is %d", descriptionMaxLen),
+			descriptionMaxLen,
+		)
 	}
 
 	return nil
 }
 
 func ValidateType(companyType string) error {
-	if availableTypesString == "" {
-		availableTypesList := make([]string, 0, len(availableTypes))
-		for availableType := range availableTypes {
-			availableTypesList = append(availableTypesList, availableType)
+	for _, t := range companyTypes {
+		if t == companyType {
+			return nil
 		}
-
-		availableTypesString = strings.Join(availableTypesList, ", ")
 	}
 
-	if _, ok := availableTypes[companyType]; !ok {
-		return fmt.Errorf(
-			"\"%s\" company type is not allowed,"+
-				" only the following types are allowed: %s",
+	return NewValidationError(
+		"type",
+		RuleEnum,
+		fmt.Sprintf(
+			"\"%s\" company type is not allowed, only the following types are allowed: %s",
 			companyType,
-			availableTypesString,
-		)
-	}
-
-	return nil
+			strings.Join(companyTypes, ", "),
+		),
+		companyTypes,
+	)
 }
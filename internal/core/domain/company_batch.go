@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// BatchMaxOperations limits the amount of operations in a single batch.
+const BatchMaxOperations = 100
+
+// Batch modes define how failures of the batch operations are handled.
+const (
+	// BatchAtomic applies either all operations of the batch or none of them.
+	BatchAtomic = "atomic"
+	// BatchBestEffort applies every valid operation independently of the others.
+	BatchBestEffort = "best_effort"
+)
+
+var (
+	batchModes      = []string{BatchAtomic, BatchBestEffort}
+	batchOperations = []string{OperationCreate, OperationUpdate, OperationDelete}
+)
+
+// BatchOperation is a single mutation of the batch, Operation is one of OperationCreate, OperationUpdate
+// and OperationDelete. Create operations create the Company, the rest of them change the company with ID,
+// checking its Version the same way single updates and deletions do.
+type BatchOperation struct {
+	Operation string
+	ID        uuid.UUID
+	Version   int
+	Company   *Company
+	Patch     CompanyPatch
+}
+
+// Validate checks the operation and returns ValidationErrors listing every violation,
+// fields of the created company and the patch are prefixed with "company." and "patch." respectively.
+func (o BatchOperation) Validate() error {
+	var errs ValidationErrors
+
+	switch o.Operation {
+	case OperationCreate:
+		if o.Company == nil {
+			return NewValidationError("company", RuleRequired, "company is required by create operation", nil)
+		}
+
+		errs = errs.Append(PrefixFields("company.", o.Company.Validate()))
+	case OperationUpdate, OperationDelete:
+		if o.ID == uuid.Nil {
+			errs = append(errs, NewValidationError("id", RuleRequired, "id is required by "+o.Operation+" operation", nil))
+		}
+
+		if o.Operation == OperationUpdate {
+			errs = errs.Append(PrefixFields("patch.", o.Patch.Validate()))
+		}
+	default:
+		return NewValidationError(
+			"op",
+			RuleEnum,
+			fmt.Sprintf("unsupported operation \"%s\", only %s are allowed", o.Operation, strings.Join(batchOperations, ", ")),
+			batchOperations,
+		)
+	}
+
+	return errs.Err()
+}
+
+// Batch is a list of operations applied by a single request. Operations of dry-run batches
+// are applied and rolled back, so they fail the same way they would, but don't change anything.
+type Batch struct {
+	Mode       string
+	DryRun     bool
+	Operations []BatchOperation
+}
+
+// Validate checks the batch and returns ValidationErrors listing every violation. Operations of atomic
+// batches are validated as well, since a single invalid operation fails the whole batch, while invalid
+// operations of best-effort batches are only skipped and have to be checked by BatchOperation.Validate.
+// Fields of the operations are prefixed with "operations.<index>.".
+func (b Batch) Validate() error {
+	var errs ValidationErrors
+
+	switch b.Mode {
+	case BatchAtomic, BatchBestEffort:
+	default:
+		errs = append(errs, NewValidationError(
+			"mode",
+			RuleEnum,
+			fmt.Sprintf("unsupported batch mode \"%s\", only %s are allowed", b.Mode, strings.Join(batchModes, ", ")),
+			batchModes,
+		))
+	}
+
+	if len(b.Operations) == 0 {
+		errs = append(errs, NewValidationError("operations", RuleMinItems, "batch has no operations", 1))
+	}
+
+	if len(b.Operations) > BatchMaxOperations {
+		errs = append(errs, NewValidationError(
+			"operations",
+			RuleMaxItems,
+			fmt.Sprintf("batch can't contain more than %d operations", BatchMaxOperations),
+			BatchMaxOperations,
+		))
+	}
+
+	if b.Mode == BatchAtomic {
+		for i, operation := range b.Operations {
+			errs = errs.Append(PrefixFields(fmt.Sprintf("operations.%d.", i), operation.Validate()))
+		}
+	}
+
+	return errs.Err()
+}
+
+// BatchResult is the outcome of a single batch operation. Company is the created or updated company,
+// Err is set if the operation failed or wasn't applied.
+type BatchResult struct {
+	Company *Company
+	Err     error
+}
+
+// PrefixFields prepends prefix to the fields of the validation errors described by err.
+func PrefixFields(prefix string, err error) error {
+	errs, ok := AsValidationErrors(err)
+	if !ok {
+		return err
+	}
+
+	prefixed := make(ValidationErrors, 0, len(errs))
+	for _, e := range errs {
+		prefixed = append(prefixed, NewValidationError(prefix+e.Field, e.Rule, e.Message, e.Limit))
+	}
+
+	return prefixed
+}
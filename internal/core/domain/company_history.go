@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OperationSnapshot = "snapshot"
+	OperationCreate   = "create"
+	OperationUpdate   = "update"
+	OperationDelete   = "delete"
+	OperationRestore  = "restore"
+	OperationPurge    = "purge"
+)
+
+// CompanyVersion is a single recorded change of a company. Before is nil for created
+// and restored companies, After is nil for deleted and purged ones. Version is the company version
+// produced by the change, see ChangedVersion.
+type CompanyVersion struct {
+	Version   int           `json:"version"`
+	Operation string        `json:"operation"`
+	Actor     string        `json:"actor"`
+	ChangedAt time.Time     `json:"changed_at"`
+	Before    *Company      `json:"before"`
+	After     *Company      `json:"after"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// ChangedVersion returns the company version produced by the change. Deleted companies are kept with
+// the next version, while purging doesn't change the version of the deleted company.
+func ChangedVersion(operation string, before, after *Company) int {
+	switch {
+	case after != nil:
+		return after.Version
+	case operation == OperationDelete:
+		return before.Version + 1
+	default:
+		return before.Version
+	}
+}
+
+// FieldChange describes a change of a single company field.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// CompanyHistory lists all versions of a company in the order they were recorded.
+type CompanyHistory struct {
+	CompanyID uuid.UUID         `json:"company_id"`
+	Versions  []*CompanyVersion `json:"versions"`
+}
+
+// DiffCompanies returns changed fields of the company, either before or after might be nil.
+func DiffCompanies(before, after *Company) []FieldChange {
+	oldValues, newValues := companyFields(before), companyFields(after)
+	changes := make([]FieldChange, 0, len(oldValues))
+
+	for i := range oldValues {
+		if oldValues[i].Value == newValues[i].Value {
+			continue
+		}
+
+		changes = append(changes, FieldChange{
+			Field: oldValues[i].Field,
+			Old:   oldValues[i].Value,
+			New:   newValues[i].Value,
+		})
+	}
+
+	return changes
+}
+
+type fieldValue struct {
+	Field string
+	Value any
+}
+
+// companyFields returns company fields in the stable order, values are nil for nil company.
+func companyFields(c *Company) []fieldValue {
+	fields := []fieldValue{
+		{Field: "name"},
+		{Field: "description"},
+		{Field: "employee_cnt"},
+		{Field: "registered"},
+		{Field: "type"},
+	}
+
+	if c != nil {
+		fields[0].Value = c.Name
+		fields[1].Value = c.Description
+		fields[2].Value = c.EmployeeCnt
+		fields[3].Value = c.Registered
+		fields[4].Value = c.Type
+	}
+
+	return fields
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ListDefaultLimit = 20
+	ListMaxLimit     = 100
+)
+
+// CompanyFilter holds optional conditions listed companies have to satisfy.
+// Nil and empty values are ignored.
+type CompanyFilter struct {
+	Type           *string
+	Registered     *bool
+	MinEmployeeCnt *int
+	MaxEmployeeCnt *int
+	NamePrefix     string
+}
+
+// ListCursor points at the last company of the previously returned page.
+// Companies are ordered by name with id as a tie-breaker, so the pair is a stable keyset.
+type ListCursor struct {
+	Name string    `json:"n"`
+	ID   uuid.UUID `json:"i"`
+}
+
+// NewListCursor returns a cursor pointing right after the given company.
+func NewListCursor(company *Company) *ListCursor {
+	return &ListCursor{Name: company.Name, ID: company.ID}
+}
+
+// Encode returns opaque string representation of the cursor.
+func (c ListCursor) Encode() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeListCursor parses cursor previously returned by ListCursor.Encode.
+func DecodeListCursor(s string) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, NewValidationError("cursor", RuleFormat, "invalid cursor", nil)
+	}
+
+	cursor := new(ListCursor)
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, NewValidationError("cursor", RuleFormat, "invalid cursor", nil)
+	}
+
+	return cursor, nil
+}
+
+// ListParams describes a single page request of the companies list.
+type ListParams struct {
+	Filter     CompanyFilter
+	Descending bool
+	Limit      int
+	After      *ListCursor
+}
+
+// Validate checks filter values and page size and returns ValidationErrors listing every violation.
+func (p *ListParams) Validate() error {
+	var errs ValidationErrors
+
+	limitMessage := fmt.Sprintf("limit must be between 1 and %d", ListMaxLimit)
+	if p.Limit < 1 {
+		errs = append(errs, NewValidationError("limit", RuleMin, limitMessage, 1))
+	} else if p.Limit > ListMaxLimit {
+		errs = append(errs, NewValidationError("limit", RuleMax, limitMessage, ListMaxLimit))
+	}
+
+	if p.Filter.Type != nil {
+		errs = errs.Append(ValidateType(*p.Filter.Type))
+	}
+
+	if p.Filter.MinEmployeeCnt != nil && p.Filter.MaxEmployeeCnt != nil &&
+		*p.Filter.MinEmployeeCnt > *p.Filter.MaxEmployeeCnt {
+		errs = append(errs, NewValidationError(
+			"employee_cnt_min",
+			RuleMax,
+			"minimal amount of employee can't exceed maximal amount",
+			*p.Filter.MaxEmployeeCnt,
+		))
+	}
+
+	return errs.Err()
+}
+
+// CompanyPage is a single page of the companies list.
+// NextCursor is empty when there are no more companies to fetch.
+type CompanyPage struct {
+	Companies  []*Company `json:"companies"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+// CompanyPatch holds new values of the company fields, nil fields are left unchanged.
+type CompanyPatch struct {
+	Name        *string
+	Description *string
+	EmployeeCnt *int
+	Registered  *bool
+	Type        *string
+}
+
+// Validate checks the changed fields and returns ValidationErrors listing every violation.
+func (p CompanyPatch) Validate() error {
+	var errs ValidationErrors
+
+	if p.Name != nil {
+		errs = errs.Append(ValidateName(*p.Name))
+	}
+
+	if p.Description != nil {
+		errs = errs.Append(ValidateDescription(*p.Description))
+	}
+
+	if p.EmployeeCnt != nil {
+		errs = errs.Append(ValidateEmployeeCnt(*p.EmployeeCnt))
+	}
+
+	if p.Type != nil {
+		errs = errs.Append(ValidateType(*p.Type))
+	}
+
+	return errs.Err()
+}
+
+// Fields returns new values of the changed fields by their names.
+func (p CompanyPatch) Fields() map[string]any {
+	fields := make(map[string]any)
+
+	if p.Name != nil {
+		fields["name"] = *p.Name
+	}
+
+	if p.Description != nil {
+		fields["description"] = *p.Description
+	}
+
+	if p.EmployeeCnt != nil {
+		fields["employee_cnt"] = *p.EmployeeCnt
+	}
+
+	if p.Registered != nil {
+		fields["registered"] = *p.Registered
+	}
+
+	if p.Type != nil {
+		fields["type"] = *p.Type
+	}
+
+	return fields
+}
+
+// Apply sets the changed fields of the company.
+func (p CompanyPatch) Apply(company *Company) {
+	if p.Name != nil {
+		company.Name = *p.Name
+	}
+
+	if p.Description != nil {
+		company.Description = *p.Description
+	}
+
+	if p.EmployeeCnt != nil {
+		company.EmployeeCnt = *p.EmployeeCnt
+	}
+
+	if p.Registered != nil {
+		company.Registered = *p.Registered
+	}
+
+	if p.Type != nil {
+		company.Type = *p.Type
+	}
+}
@@ -1,9 +1,11 @@
 package domain_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -158,3 +160,193 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateErrors(t *testing.T) {
+	t.Parallel()
+
+	company := domain.Company{Name: "na", Type: "NonProfit", EmployeeCnt: -1}
+
+	errs, ok := domain.AsValidationErrors(company.Validate())
+	assert.True(t, ok)
+	assert.Equal(t, domain.ValidationErrors{
+		{
+			Field:   "name",
+			Rule:    domain.RuleMinLength,
+			Message: "company name is too short, minimal length is 3",
+			Limit:   3,
+		},
+		{
+			Field:   "employee_cnt",
+			Rule:    domain.RuleMin,
+			Message: "amount of employee can't be negative",
+			Limit:   0,
+		},
+	}, errs)
+	assert.EqualError(t, errs, "company name is too short, minimal length is 3; amount of employee can't be negative")
+
+	_, ok = domain.AsValidationErrors(errors.New("not a validation error"))
+	assert.False(t, ok)
+}
+
+func TestListCursor(t *testing.T) {
+	t.Parallel()
+
+	cursor := domain.ListCursor{Name: "some name", ID: uuid.New()}
+
+	decoded, err := domain.DecodeListCursor(cursor.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, &cursor, decoded)
+
+	for _, invalid := range []string{"", "not a cursor", "e30"} {
+		_, err := domain.DecodeListCursor(invalid)
+		assert.Error(t, err)
+	}
+}
+
+func TestListParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		validType   = "NonProfit"
+		invalidType = "NonaProfit"
+		one, two    = 1, 2
+	)
+
+	testCases := []struct {
+		Input   domain.ListParams
+		IsValid bool
+	}{
+		{
+			domain.ListParams{Limit: domain.ListDefaultLimit},
+			true,
+		},
+		{
+			domain.ListParams{Limit: 0},
+			false,
+		},
+		{
+			domain.ListParams{Limit: domain.ListMaxLimit + 1},
+			false,
+		},
+		{
+			domain.ListParams{Limit: 1, Filter: domain.CompanyFilter{Type: &validType}},
+			true,
+		},
+		{
+			domain.ListParams{Limit: 1, Filter: domain.CompanyFilter{Type: &invalidType}},
+			false,
+		},
+		{
+			domain.ListParams{Limit: 1, Filter: domain.CompanyFilter{MinEmployeeCnt: &one, MaxEmployeeCnt: &two}},
+			true,
+		},
+		{
+			domain.ListParams{Limit: 1, Filter: domain.CompanyFilter{MinEmployeeCnt: &two, MaxEmployeeCnt: &one}},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Input.Validate()
+		if tc.IsValid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestDiffCompanies(t *testing.T) {
+	t.Parallel()
+
+	before := &domain.Company{Name: "name", Description: "desc", EmployeeCnt: 1, Type: "NonProfit"}
+	after := &domain.Company{Name: "name", Description: "desc", EmployeeCnt: 2, Registered: true, Type: "NonProfit"}
+
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "employee_cnt", Old: 1, New: 2},
+		{Field: "registered", Old: false, New: true},
+	}, domain.DiffCompanies(before, after))
+
+	assert.Empty(t, domain.DiffCompanies(before, before))
+	assert.Len(t, domain.DiffCompanies(nil, after), 5)
+
+	for _, change := range domain.DiffCompanies(before, nil) {
+		assert.Nil(t, change.New)
+	}
+}
+
+func TestCompanyPatch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		name  = "new name"
+		empty = ""
+		cnt   = -1
+	)
+
+	patch := domain.CompanyPatch{Name: &name, Description: &empty}
+	assert.NoError(t, patch.Validate())
+	assert.Equal(t, map[string]any{"name": name, "description": ""}, patch.Fields())
+
+	company := &domain.Company{Name: "name", Description: "desc", EmployeeCnt: 1, Type: "NonProfit"}
+	patch.Apply(company)
+	assert.Equal(t, &domain.Company{Name: name, EmployeeCnt: 1, Type: "NonProfit"}, company)
+
+	errs, ok := domain.AsValidationErrors(domain.CompanyPatch{EmployeeCnt: &cnt, Type: &empty}.Validate())
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+
+	assert.NoError(t, domain.CompanyPatch{}.Validate())
+	assert.Empty(t, domain.CompanyPatch{}.Fields())
+}
+
+func TestBatchValidate(t *testing.T) {
+	t.Parallel()
+
+	name := "na"
+	create := domain.BatchOperation{Operation: domain.OperationCreate, Company: &domain.Company{Name: "name", Type: "NonProfit"}}
+	invalidUpdate := domain.BatchOperation{Operation: domain.OperationUpdate, Patch: domain.CompanyPatch{Name: &name}}
+
+	testCases := []struct {
+		Input  domain.Batch
+		Fields []string
+	}{
+		{
+			domain.Batch{Mode: domain.BatchAtomic, Operations: []domain.BatchOperation{create}},
+			nil,
+		},
+		{
+			domain.Batch{Mode: "all"},
+			[]string{"mode", "operations"},
+		},
+		{
+			domain.Batch{Mode: domain.BatchBestEffort, Operations: make([]domain.BatchOperation, domain.BatchMaxOperations+1)},
+			[]string{"operations"},
+		},
+		{
+			domain.Batch{Mode: domain.BatchAtomic, Operations: []domain.BatchOperation{create, invalidUpdate, {}}},
+			[]string{"operations.1.id", "operations.1.patch.name", "operations.2.op"},
+		},
+		{
+			domain.Batch{Mode: domain.BatchBestEffort, Operations: []domain.BatchOperation{create, invalidUpdate}},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.Input.Validate()
+		if tc.Fields == nil {
+			assert.NoError(t, err)
+			continue
+		}
+
+		errs, _ := domain.AsValidationErrors(err)
+
+		fields := make([]string, 0, len(errs))
+		for _, e := range errs {
+			fields = append(fields, e.Field)
+		}
+
+		assert.Equal(t, tc.Fields, fields)
+	}
+}
@@ -8,6 +8,10 @@ import (
 
 var ErrInternalServer = fmt.Errorf("internal server error")
 
+// ErrBatchAborted is the result of operations which weren't applied, because another operation
+// of the same atomic batch failed.
+var ErrBatchAborted = fmt.Errorf("operation wasn't applied, because another operation of the atomic batch failed")
+
 type CompanyNotFoundError struct {
 	ID uuid.UUID
 }
@@ -31,3 +35,20 @@ func NewNameAlreadyTakenError(name string) *NameAlreadyTakenError {
 func (e NameAlreadyTakenError) Error() string {
 	return fmt.Sprintf("company with the name \"%s\" already exists", e.Name)
 }
+
+type VersionMismatchError struct {
+	ID       uuid.UUID
+	Expected int
+	Actual   int
+}
+
+func NewVersionMismatchError(id uuid.UUID, expected, actual int) *VersionMismatchError {
+	return &VersionMismatchError{ID: id, Expected: expected, Actual: actual}
+}
+
+func (e VersionMismatchError) Error() string {
+	return fmt.Sprintf(
+		"company with id \"%s\" has version %d, but version %d was expected",
+		e.ID, e.Actual, e.Expected,
+	)
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validation rules reported by ValidationError.
+const (
+	RuleRequired  = "required"
+	RuleType      = "type"
+	RuleFormat    = "format"
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleMin       = "min"
+	RuleMax       = "max"
+	RuleEnum      = "enum"
+	RuleMinItems  = "min_items"
+	RuleMaxItems  = "max_items"
+	RuleNotNull   = "not_null"
+	RuleReadOnly  = "read_only"
+	RuleUnknown   = "unknown"
+)
+
+// ValidationError describes a single field violating a validation rule.
+// Limit holds the bound or the allowed values of the rule, if it has any.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Limit   any    `json:"limit,omitempty"`
+}
+
+func NewValidationError(field, rule, message string, limit any) *ValidationError {
+	return &ValidationError{Field: field, Rule: rule, Message: message, Limit: limit}
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors lists all violations found in a validated value.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Append adds err to the list if it's a validation error, other errors are ignored.
+func (e ValidationErrors) Append(err error) ValidationErrors {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return append(e, validationErr)
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return append(e, validationErrs...)
+	}
+
+	return e
+}
+
+// Err returns the list as an error or nil if it's empty.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// AsValidationErrors returns violations described by err, which is either
+// a ValidationError or ValidationErrors.
+func AsValidationErrors(err error) (ValidationErrors, bool) {
+	errs := ValidationErrors(nil).Append(err)
+
+	return errs, len(errs) > 0
+}
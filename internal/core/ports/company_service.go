@@ -9,7 +9,19 @@ import (
 
 type CompanyService interface {
 	Get(ctx context.Context, id uuid.UUID) (*domain.Company, error)
+	List(ctx context.Context, params domain.ListParams) (*domain.CompanyPage, error)
 	Create(ctx context.Context, company *domain.Company) error
-	Update(ctx context.Context, id uuid.UUID, fieldsToUpdate map[string]any) error
-	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Export passes companies matching the filter to fn page by page, errors returned by fn stop the export.
+	Export(ctx context.Context, filter domain.CompanyFilter, fn func(companies []*domain.Company) error) error
+
+	// Update and Delete check the company version unless it's zero.
+	Update(ctx context.Context, id uuid.UUID, version int, patch domain.CompanyPatch) error
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+
+	// Batch applies the operations of the batch and returns the result of every operation in the same order.
+	// Invalid atomic batches are rejected as a whole, while invalid operations of best-effort batches are skipped.
+	Batch(ctx context.Context, batch domain.Batch) ([]*domain.BatchResult, error)
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Company, error)
+	History(ctx context.Context, id uuid.UUID) (*domain.CompanyHistory, error)
 }
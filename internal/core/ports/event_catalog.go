@@ -0,0 +1,151 @@
+package ports
+
+import (
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/google/uuid"
+)
+
+// Names of the company mutation events.
+const (
+	EventCompanyCreated  = "CompanyCreated"
+	EventCompanyUpdated  = "CompanyUpdated"
+	EventCompanyDeleted  = "CompanyDeleted"
+	EventCompanyRestored = "CompanyRestored"
+	EventCompanyPurged   = "CompanyPurged"
+)
+
+// EventData is the payload of the company mutation event, its type determines the event name.
+type EventData interface {
+	EventName() string
+}
+
+// EventKind describes the events of a single name. SchemaVersion is incremented whenever the payload
+// changes in a way breaking existing consumers: a field is removed, renamed, changes its type or stops
+// being always present. Adding fields doesn't change the version, so consumers have to ignore unknown ones.
+type EventKind struct {
+	Name          string
+	SchemaVersion int
+	// NewData returns an empty payload, events are decoded into it.
+	NewData func() EventData
+}
+
+// EventKinds lists all kinds of the company mutation events.
+func EventKinds() []EventKind {
+	return []EventKind{
+		{
+			Name:          EventCompanyCreated,
+			SchemaVersion: 1,
+			NewData:       func() EventData { return new(CompanyCreatedData) },
+		},
+		{
+			Name:          EventCompanyUpdated,
+			SchemaVersion: 1,
+			NewData:       func() EventData { return new(CompanyUpdatedData) },
+		},
+		{
+			Name:          EventCompanyDeleted,
+			SchemaVersion: 1,
+			NewData:       func() EventData { return new(CompanyDeletedData) },
+		},
+		{
+			Name:          EventCompanyRestored,
+			SchemaVersion: 1,
+			NewData:       func() EventData { return new(CompanyRestoredData) },
+		},
+		{
+			Name:          EventCompanyPurged,
+			SchemaVersion: 1,
+			NewData:       func() EventData { return new(CompanyPurgedData) },
+		},
+	}
+}
+
+// LookupEventKind returns the kind of events with the name.
+func LookupEventKind(name string) (EventKind, bool) {
+	for _, kind := range EventKinds() {
+		if kind.Name == name {
+			return kind, true
+		}
+	}
+
+	return EventKind{}, false
+}
+
+// CompanyCreatedData holds the created company.
+type CompanyCreatedData struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EmployeeCnt int       `json:"employee_cnt"`
+	Registered  bool      `json:"registered"`
+	Type        string    `json:"type"`
+	Version     int       `json:"version"`
+}
+
+func NewCompanyCreatedData(company *domain.Company) *CompanyCreatedData {
+	return &CompanyCreatedData{
+		ID:          company.ID,
+		Name:        company.Name,
+		Description: company.Description,
+		EmployeeCnt: company.EmployeeCnt,
+		Registered:  company.Registered,
+		Type:        company.Type,
+		Version:     company.Version,
+	}
+}
+
+func (CompanyCreatedData) EventName() string {
+	return EventCompanyCreated
+}
+
+// CompanyUpdatedData holds new values of the changed fields only, unchanged fields are omitted.
+type CompanyUpdatedData struct {
+	ID          uuid.UUID `json:"id"`
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	EmployeeCnt *int      `json:"employee_cnt,omitempty"`
+	Registered  *bool     `json:"registered,omitempty"`
+	Type        *string   `json:"type,omitempty"`
+}
+
+func NewCompanyUpdatedData(id uuid.UUID, patch domain.CompanyPatch) *CompanyUpdatedData {
+	return &CompanyUpdatedData{
+		ID:          id,
+		Name:        patch.Name,
+		Description: patch.Description,
+		EmployeeCnt: patch.EmployeeCnt,
+		Registered:  patch.Registered,
+		Type:        patch.Type,
+	}
+}
+
+func (CompanyUpdatedData) EventName() string {
+	return EventCompanyUpdated
+}
+
+// CompanyDeletedData identifies the deleted company.
+type CompanyDeletedData struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func (CompanyDeletedData) EventName() string {
+	return EventCompanyDeleted
+}
+
+// CompanyRestoredData identifies the restored company.
+type CompanyRestoredData struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func (CompanyRestoredData) EventName() string {
+	return EventCompanyRestored
+}
+
+// CompanyPurgedData identifies the permanently removed company.
+type CompanyPurgedData struct {
+	ID uuid.UUID `json:"id"`
+}
+
+func (CompanyPurgedData) EventName() string {
+	return EventCompanyPurged
+}
@@ -2,30 +2,108 @@ package ports
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
 	"github.com/google/uuid"
 )
 
+// CompanyMutationEvent is the envelope of the payload describing the mutation. SchemaVersion is the version
+// of the payload schema of events with the Name, see EventKind.
 type CompanyMutationEvent struct {
-	ID       uuid.UUID `json:"id"`
-	Name     string    `json:"name"`
-	Time     time.Time `json:"time"`
-	Producer string    `json:"producer"`
-	Data     any       `json:"data"`
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	SchemaVersion int       `json:"schema_version"`
+	Time          time.Time `json:"time"`
+	Producer      string    `json:"producer"`
+	CompanyID     uuid.UUID `json:"company_id"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Data          EventData `json:"data"`
+
+	// TraceContext holds W3C trace context headers of the operation which produced the event.
+	// It isn't a part of the event payload and is passed in the message headers instead.
+	TraceContext map[string]string `json:"-"`
 }
 
-func NewCompanyMutationEvent(eventName string, producer string, data any) *CompanyMutationEvent {
+// NewCompanyMutationEvent creates the event of the mutation performed within ctx,
+// the event is named after its payload and is correlated with the request by its id if ctx carries one.
+func NewCompanyMutationEvent(
+	ctx context.Context,
+	producer string,
+	companyID uuid.UUID,
+	data EventData,
+) *CompanyMutationEvent {
+	kind, _ := LookupEventKind(data.EventName())
+
 	return &CompanyMutationEvent{
-		ID:       uuid.New(),
-		Name:     eventName,
-		Time:     time.Now(),
-		Producer: producer,
-		Data:     data,
+		ID:            uuid.New(),
+		Name:          kind.Name,
+		SchemaVersion: kind.SchemaVersion,
+		Time:          time.Now(),
+		Producer:      producer,
+		CompanyID:     companyID,
+		RequestID:     domain.RequestIDFromContext(ctx),
+		Data:          data,
+	}
+}
+
+// UnmarshalJSON decodes the event together with its payload, which is decoded into the type of the payload
+// of events with the same name. Events of unknown names can't be decoded. Events stored before schema versions
+// were introduced have no version, their payloads match the first one.
+func (e *CompanyMutationEvent) UnmarshalJSON(b []byte) error {
+	// event has the same fields, but not the methods, so decoding into it doesn't recurse
+	type event CompanyMutationEvent
+
+	envelope := struct {
+		*event
+		Data json.RawMessage `json:"data"`
+	}{event: (*event)(e)}
+
+	if err := json.Unmarshal(b, &envelope); err != nil {
+		return err
+	}
+
+	kind, ok := LookupEventKind(e.Name)
+	if !ok {
+		return fmt.Errorf("unknown event %q", e.Name)
+	}
+
+	data := kind.NewData()
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return fmt.Errorf("%s event data: %w", e.Name, err)
 	}
+
+	if e.SchemaVersion == 0 {
+		e.SchemaVersion = 1
+	}
+
+	e.Data = data
+
+	return nil
+}
+
+// Key returns the key events of the same company are partitioned by,
+// so consumers receive them in the order they were produced.
+func (e *CompanyMutationEvent) Key() []byte {
+	return []byte(e.CompanyID.String())
+}
+
+// Headers returns headers which have to be sent along with the event payload.
+func (e *CompanyMutationEvent) Headers() map[string]string {
+	return e.TraceContext
+}
+
+// OutboxMessage is an event stored in the transactional outbox and waiting to be relayed.
+type OutboxMessage struct {
+	ID       int64
+	Event    *CompanyMutationEvent
+	Attempts int
 }
 
 type EventsWriter interface {
 	Write(ctx context.Context, data ...any) error
-	Close()
+	// Close flushes pending writes and releases the writer.
+	Close() error
 }
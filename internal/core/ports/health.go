@@ -0,0 +1,12 @@
+package ports
+
+import "context"
+
+// HealthCheck checks availability of a single dependency of the app.
+type HealthCheck struct {
+	Name string
+	// Required dependencies make the app not ready when they are down,
+	// others are only reported.
+	Required bool
+	Check    func(ctx context.Context) error
+}
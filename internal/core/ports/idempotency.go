@@ -0,0 +1,46 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord is the response stored for a request made with an idempotency key.
+type IdempotencyRecord struct {
+	Key string
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string
+	// Completed is false while the first request with the key is still being processed.
+	Completed  bool
+	StatusCode int
+	Header     map[string]string
+	Body       []byte
+}
+
+// IdempotencyStore keeps responses of requests made with idempotency keys until they expire.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey stores an incomplete record of the key, which expires after lockTimeout
+	// unless completed, and returns it with reserved set to true. If an unexpired record of the key
+	// already exists, it is returned instead with reserved set to false.
+	ReserveIdempotencyKey(
+		ctx context.Context,
+		key, fingerprint string,
+		lockTimeout time.Duration,
+	) (record *IdempotencyRecord, reserved bool, err error)
+
+	// CompleteIdempotencyKey stores the response of the reserved key and extends its expiration by ttl.
+	CompleteIdempotencyKey(
+		ctx context.Context,
+		key string,
+		ttl time.Duration,
+		statusCode int,
+		header map[string]string,
+		body []byte,
+	) error
+
+	// ReleaseIdempotencyKey removes the reserved key, so the request can be retried with it.
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+
+	// DeleteExpiredIdempotencyKeys removes expired records and returns their amount.
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,8 @@
+package ports
+
+// Metrics records business metrics of the app.
+type Metrics interface {
+	// CompanyMutated counts committed company mutations by their operation,
+	// which is one of domain.Operation* constants.
+	CompanyMutated(operation string)
+}
@@ -0,0 +1,158 @@
+package portstest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// EventsHarness is the writer under test together with the way to observe what it has published.
+type EventsHarness struct {
+	Writer ports.EventsWriter
+
+	// Published returns payloads of all events published through Writer in the order they were
+	// published, waiting until at least n of them are observed. It fails the test if they never are.
+	Published func(t *testing.T, n int) [][]byte
+}
+
+// RunEventsWriter runs the conformance suite of ports.EventsWriter. newHarness is called by every test
+// and has to return a writer which hasn't published anything yet, the suite closes it.
+func RunEventsWriter(t *testing.T, newHarness func(t *testing.T) *EventsHarness) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, h *EventsHarness)
+	}{
+		{"Write", testWrite},
+		{"WriteOrder", testWriteOrder},
+		{"ConcurrentWrites", testConcurrentWrites},
+		{"Close", testClose},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newHarness(t))
+		})
+	}
+}
+
+func newEvents(companyID uuid.UUID, n int) []any {
+	events := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		events = append(events, newEvent(ports.EventCompanyUpdated, companyID))
+	}
+
+	return events
+}
+
+// decodeEvents decodes published payloads and checks they are the JSON representation of the events.
+func decodeEvents(t *testing.T, payloads [][]byte) []*ports.CompanyMutationEvent {
+	t.Helper()
+
+	events := make([]*ports.CompanyMutationEvent, 0, len(payloads))
+
+	for _, payload := range payloads {
+		event := new(ports.CompanyMutationEvent)
+		require.NoError(t, json.Unmarshal(payload, event))
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func assertEvent(t *testing.T, want any, got *ports.CompanyMutationEvent) {
+	t.Helper()
+
+	wantEvent, ok := want.(*ports.CompanyMutationEvent)
+	require.True(t, ok)
+
+	assert.Equal(t, wantEvent.ID, got.ID)
+	assert.Equal(t, wantEvent.Name, got.Name)
+	assert.Equal(t, wantEvent.SchemaVersion, got.SchemaVersion)
+	assert.Equal(t, wantEvent.Producer, got.Producer)
+	assert.Equal(t, wantEvent.CompanyID, got.CompanyID)
+	assert.True(t, wantEvent.Time.Equal(got.Time))
+	assert.Equal(t, wantEvent.Data, got.Data)
+}
+
+func testWrite(t *testing.T, h *EventsHarness) {
+	defer h.Writer.Close()
+
+	events := newEvents(uuid.New(), 3)
+	require.NoError(t, h.Writer.Write(context.Background(), events...))
+	require.NoError(t, h.Writer.Write(context.Background()), "empty writes have to succeed")
+
+	published := decodeEvents(t, h.Published(t, len(events)))
+	require.Len(t, published, len(events))
+
+	for i, event := range events {
+		assertEvent(t, event, published[i])
+	}
+}
+
+// testWriteOrder checks that events of the same company are published in the order they were written.
+func testWriteOrder(t *testing.T, h *EventsHarness) {
+	defer h.Writer.Close()
+
+	companyID := uuid.New()
+	events := newEvents(companyID, 6)
+
+	for i := 0; i < len(events); i += 2 {
+		require.NoError(t, h.Writer.Write(context.Background(), events[i:i+2]...))
+	}
+
+	published := decodeEvents(t, h.Published(t, len(events)))
+	require.Len(t, published, len(events))
+
+	for i, event := range events {
+		assertEvent(t, event, published[i])
+	}
+}
+
+func testConcurrentWrites(t *testing.T, h *EventsHarness) {
+	defer h.Writer.Close()
+
+	events := make([]any, 0, concurrency)
+	for i := 0; i < concurrency; i++ {
+		events = append(events, newEvents(uuid.New(), 1)...)
+	}
+
+	errs := runConcurrently(func(i int) error {
+		return h.Writer.Write(context.Background(), events[i])
+	})
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	published := decodeEvents(t, h.Published(t, len(events)))
+	require.Len(t, published, len(events))
+
+	publishedIDs := make([]uuid.UUID, 0, len(published))
+	for _, event := range published {
+		publishedIDs = append(publishedIDs, event.ID)
+	}
+
+	for _, event := range events {
+		assert.Contains(t, publishedIDs, event.(*ports.CompanyMutationEvent).ID)
+	}
+}
+
+// testClose checks that events written before Close are published.
+func testClose(t *testing.T, h *EventsHarness) {
+	events := newEvents(uuid.New(), 2)
+	require.NoError(t, h.Writer.Write(context.Background(), events...))
+	require.NoError(t, h.Writer.Close())
+
+	published := decodeEvents(t, h.Published(t, len(events)))
+	require.Len(t, published, len(events))
+}
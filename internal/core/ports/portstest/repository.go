@@ -0,0 +1,697 @@
+// Package portstest provides conformance suites checking that adapters implement the ports the way
+// the services expect, so every adapter is tested against the same contract.
+package portstest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	producer    = "portstest"
+	actor       = "portstest:actor"
+	concurrency = 10
+)
+
+// RunRepository runs the conformance suite of ports.Repository. newRepo is called by every test
+// and has to return a repository without any companies, history and outbox messages.
+func RunRepository(t *testing.T, newRepo func(t *testing.T) ports.Repository) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo ports.Repository)
+	}{
+		{"CreateCompany", testCreateCompany},
+		{"UpdateCompany", testUpdateCompany},
+		{"DeleteCompany", testDeleteCompany},
+		{"RestoreCompany", testRestoreCompany},
+		{"PurgeCompany", testPurgeCompany},
+		{"ListCompanies", testListCompanies},
+		{"ApplyBatch", testApplyBatch},
+		{"GetCompanyHistory", testGetCompanyHistory},
+		{"Outbox", testOutbox},
+		{"StoreOutbox", testStoreOutbox},
+		{"ConcurrentFetches", testConcurrentFetches},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo(t))
+		})
+	}
+}
+
+var (
+	companyNotFoundErr  *domain.CompanyNotFoundError
+	nameAlreadyTakenErr *domain.NameAlreadyTakenError
+	versionMismatchErr  *domain.VersionMismatchError
+)
+
+func newContext() context.Context {
+	return domain.WithActor(context.Background(), actor)
+}
+
+func newCompany(name string) *domain.Company {
+	return &domain.Company{
+		Name:        name,
+		Description: "description of " + name,
+		EmployeeCnt: 10,
+		Registered:  true,
+		Type:        "NonProfit",
+	}
+}
+
+// newEvent returns the event with the name, payloads are opaque to the repository, so they only identify the company.
+func newEvent(name string, id uuid.UUID) *ports.CompanyMutationEvent {
+	var data ports.EventData
+
+	switch name {
+	case ports.EventCompanyCreated:
+		data = &ports.CompanyCreatedData{ID: id}
+	case ports.EventCompanyUpdated:
+		data = &ports.CompanyUpdatedData{ID: id}
+	case ports.EventCompanyDeleted:
+		data = &ports.CompanyDeletedData{ID: id}
+	case ports.EventCompanyRestored:
+		data = &ports.CompanyRestoredData{ID: id}
+	case ports.EventCompanyPurged:
+		data = &ports.CompanyPurgedData{ID: id}
+	}
+
+	return ports.NewCompanyMutationEvent(context.Background(), producer, id, data)
+}
+
+// create stores a new company with the given name and fails the test if it can't.
+// The id is set beforehand, the same way the service does it to reference the company in the event.
+func create(t *testing.T, repo ports.Repository, name string) *domain.Company {
+	t.Helper()
+
+	company := newCompany(name)
+	company.SetID()
+	require.NoError(t, repo.CreateCompany(newContext(), company, newEvent(ports.EventCompanyCreated, company.ID)))
+
+	return company
+}
+
+// drainOutbox removes outbox messages which are due for delivery and returns their amount.
+func drainOutbox(t *testing.T, repo ports.Repository) int {
+	t.Helper()
+
+	drained := 0
+
+	for {
+		messages, err := repo.FetchOutbox(context.Background(), 1000)
+		require.NoError(t, err)
+
+		if len(messages) == 0 {
+			return drained
+		}
+
+		ids := make([]int64, 0, len(messages))
+		for _, message := range messages {
+			ids = append(ids, message.ID)
+		}
+
+		require.NoError(t, repo.DeleteOutbox(context.Background(), ids...))
+
+		drained += len(messages)
+	}
+}
+
+func testCreateCompany(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+
+	company := newCompany("created")
+	require.NoError(t, repo.CreateCompany(ctx, company, newEvent(ports.EventCompanyCreated, company.ID)))
+	assert.NotEqual(t, uuid.Nil, company.ID, "missing ids have to be generated")
+	assert.Equal(t, 1, company.Version)
+
+	stored, err := repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, company, stored)
+
+	withID := create(t, repo, "with id")
+
+	stored, err = repo.GetCompanyByID(ctx, withID.ID)
+	require.NoError(t, err)
+	assert.Equal(t, withID, stored, "provided ids have to be kept")
+
+	duplicate := newCompany("created")
+	err = repo.CreateCompany(ctx, duplicate, newEvent(ports.EventCompanyCreated, duplicate.ID))
+	assert.ErrorAs(t, err, &nameAlreadyTakenErr)
+	assert.Equal(t, 2, drainOutbox(t, repo), "failed mutations mustn't store events")
+
+	_, err = repo.GetCompanyByID(ctx, uuid.New())
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+}
+
+func testUpdateCompany(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	company := create(t, repo, "updated")
+	create(t, repo, "taken")
+
+	employeeCnt, registered := 20, false
+	patch := domain.CompanyPatch{EmployeeCnt: &employeeCnt, Registered: &registered}
+	require.NoError(t, repo.UpdateCompany(ctx, company.ID, 1, patch, newEvent(ports.EventCompanyUpdated, company.ID)))
+
+	want := *company
+	want.EmployeeCnt, want.Registered, want.Version = employeeCnt, registered, 2
+
+	stored, err := repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &want, stored, "fields missing from the patch have to be kept")
+
+	// zero version skips the check, the company keeps its own name
+	name := company.Name
+	require.NoError(t, repo.UpdateCompany(ctx, company.ID, 0, domain.CompanyPatch{Name: &name}, newEvent(ports.EventCompanyUpdated, company.ID)))
+
+	stored, err = repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Version)
+
+	drainOutbox(t, repo)
+
+	taken := "taken"
+	err = repo.UpdateCompany(ctx, company.ID, 0, domain.CompanyPatch{Name: &taken}, newEvent(ports.EventCompanyUpdated, company.ID))
+	assert.ErrorAs(t, err, &nameAlreadyTakenErr)
+
+	err = repo.UpdateCompany(ctx, company.ID, 1, patch, newEvent(ports.EventCompanyUpdated, company.ID))
+	require.ErrorAs(t, err, &versionMismatchErr)
+	assert.Equal(t, 1, versionMismatchErr.Expected)
+	assert.Equal(t, 3, versionMismatchErr.Actual)
+
+	id := uuid.New()
+	err = repo.UpdateCompany(ctx, id, 0, patch, newEvent(ports.EventCompanyUpdated, id))
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+
+	stored, err = repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Version, "failed updates mustn't change the company")
+	assert.Zero(t, drainOutbox(t, repo), "failed updates mustn't store events")
+}
+
+func testDeleteCompany(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	company := create(t, repo, "deleted")
+
+	err := repo.DeleteCompany(ctx, company.ID, 2, newEvent(ports.EventCompanyDeleted, company.ID))
+	assert.ErrorAs(t, err, &versionMismatchErr)
+
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 1, newEvent(ports.EventCompanyDeleted, company.ID)))
+
+	_, err = repo.GetCompanyByID(ctx, company.ID)
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+
+	err = repo.DeleteCompany(ctx, company.ID, 0, newEvent(ports.EventCompanyDeleted, company.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr, "deleted companies are treated as nonexistent")
+
+	employeeCnt := 1
+	err = repo.UpdateCompany(ctx, company.ID, 0, domain.CompanyPatch{EmployeeCnt: &employeeCnt}, newEvent(ports.EventCompanyUpdated, company.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr, "deleted companies are treated as nonexistent")
+
+	id := uuid.New()
+	err = repo.DeleteCompany(ctx, id, 0, newEvent(ports.EventCompanyDeleted, id))
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+
+	create(t, repo, "deleted")
+}
+
+func testRestoreCompany(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	company := create(t, repo, "restored")
+
+	_, err := repo.RestoreCompany(ctx, company.ID, newEvent(ports.EventCompanyRestored, company.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr, "active companies can't be restored")
+
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 0, newEvent(ports.EventCompanyDeleted, company.ID)))
+
+	restored, err := repo.RestoreCompany(ctx, company.ID, newEvent(ports.EventCompanyRestored, company.ID))
+	require.NoError(t, err)
+
+	want := *company
+	want.Version = 3
+	assert.Equal(t, &want, restored)
+
+	stored, err := repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &want, stored)
+
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 0, newEvent(ports.EventCompanyDeleted, company.ID)))
+	create(t, repo, "restored")
+
+	_, err = repo.RestoreCompany(ctx, company.ID, newEvent(ports.EventCompanyRestored, company.ID))
+	assert.ErrorAs(t, err, &nameAlreadyTakenErr)
+
+	id := uuid.New()
+	_, err = repo.RestoreCompany(ctx, id, newEvent(ports.EventCompanyRestored, id))
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+}
+
+func testPurgeCompany(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	first, second, active := create(t, repo, "first"), create(t, repo, "second"), create(t, repo, "active")
+
+	require.NoError(t, repo.DeleteCompany(ctx, first.ID, 0, newEvent(ports.EventCompanyDeleted, first.ID)))
+	require.NoError(t, repo.DeleteCompany(ctx, second.ID, 0, newEvent(ports.EventCompanyDeleted, second.ID)))
+
+	ids, err := repo.ListPurgeableCompanies(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, ids, "companies deleted after deletedBefore aren't purgeable")
+
+	ids, err = repo.ListPurgeableCompanies(ctx, time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{first.ID, second.ID}, ids, "companies deleted earlier have to be purged first")
+
+	ids, err = repo.ListPurgeableCompanies(ctx, time.Now().Add(time.Hour), 1)
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	err = repo.PurgeCompany(ctx, active.ID, newEvent(ports.EventCompanyPurged, active.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr, "active companies can't be purged")
+
+	require.NoError(t, repo.PurgeCompany(ctx, first.ID, newEvent(ports.EventCompanyPurged, first.ID)))
+
+	err = repo.PurgeCompany(ctx, first.ID, newEvent(ports.EventCompanyPurged, first.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+
+	_, err = repo.RestoreCompany(ctx, first.ID, newEvent(ports.EventCompanyRestored, first.ID))
+	assert.ErrorAs(t, err, &companyNotFoundErr, "purged companies can't be restored")
+
+	history, err := repo.GetCompanyHistory(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 3, "history of purged companies has to be kept")
+	assert.Equal(t, domain.OperationPurge, history[2].Operation)
+}
+
+func testListCompanies(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	names := []string{"b", "a", "d", "c"}
+	companies := make(map[string]*domain.Company, len(names))
+
+	for _, name := range names {
+		companies[name] = create(t, repo, name)
+	}
+
+	deleted := create(t, repo, "deleted")
+	require.NoError(t, repo.DeleteCompany(ctx, deleted.ID, 0, newEvent(ports.EventCompanyDeleted, deleted.ID)))
+
+	list := func(params domain.ListParams) []string {
+		t.Helper()
+
+		if params.Limit == 0 {
+			params.Limit = domain.ListMaxLimit
+		}
+
+		listed, err := repo.ListCompanies(ctx, params)
+		require.NoError(t, err)
+
+		listedNames := make([]string, 0, len(listed))
+		for _, company := range listed {
+			listedNames = append(listedNames, company.Name)
+		}
+
+		return listedNames
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, list(domain.ListParams{}), "deleted companies mustn't be listed")
+	assert.Equal(t, []string{"d", "c", "b", "a"}, list(domain.ListParams{Descending: true}))
+	assert.Equal(t, []string{"a", "b"}, list(domain.ListParams{Limit: 2}))
+	assert.Equal(t, []string{"c", "d"}, list(domain.ListParams{Limit: 2, After: domain.NewListCursor(companies["b"])}))
+	assert.Equal(t, []string{"b", "a"}, list(domain.ListParams{Descending: true, After: domain.NewListCursor(companies["c"])}))
+
+	employeeCnt, registered, companyType := 50, false, "Cooperative"
+	patch := domain.CompanyPatch{EmployeeCnt: &employeeCnt, Registered: &registered, Type: &companyType}
+	require.NoError(t, repo.UpdateCompany(ctx, companies["b"].ID, 0, patch, newEvent(ports.EventCompanyUpdated, companies["b"].ID)))
+
+	minEmployeeCnt, maxEmployeeCnt := 20, 10
+
+	assert.Equal(t, []string{"b"}, list(domain.ListParams{Filter: domain.CompanyFilter{Type: &companyType}}))
+	assert.Equal(t, []string{"b"}, list(domain.ListParams{Filter: domain.CompanyFilter{Registered: &registered}}))
+	assert.Equal(t, []string{"b"}, list(domain.ListParams{Filter: domain.CompanyFilter{MinEmployeeCnt: &minEmployeeCnt}}))
+	assert.Equal(t, []string{"a", "c", "d"}, list(domain.ListParams{
+		Filter: domain.CompanyFilter{MaxEmployeeCnt: &maxEmployeeCnt},
+	}))
+
+	// wildcards of LIKE patterns have to be matched literally
+	create(t, repo, "x_y")
+	create(t, repo, "x%")
+	create(t, repo, "X_y")
+
+	assert.Equal(t, []string{"x_y"}, list(domain.ListParams{Filter: domain.CompanyFilter{NamePrefix: "x_"}}))
+	assert.Equal(t, []string{"x%"}, list(domain.ListParams{Filter: domain.CompanyFilter{NamePrefix: "x%"}}))
+}
+
+func testApplyBatch(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	existing := create(t, repo, "existing")
+	drainOutbox(t, repo)
+
+	name := "renamed"
+	operations := func(duplicateName string) []*ports.BatchOperation {
+		created, duplicate := newCompany("created"), newCompany(duplicateName)
+
+		return []*ports.BatchOperation{
+			{
+				BatchOperation: domain.BatchOperation{Operation: domain.OperationCreate, Company: created},
+				Event:          newEvent(ports.EventCompanyCreated, created.ID),
+			},
+			{
+				BatchOperation: domain.BatchOperation{
+					Operation: domain.OperationUpdate,
+					ID:        existing.ID,
+					Patch:     domain.CompanyPatch{Name: &name},
+				},
+				Event: newEvent(ports.EventCompanyUpdated, existing.ID),
+			},
+			{
+				BatchOperation: domain.BatchOperation{Operation: domain.OperationCreate, Company: duplicate},
+				Event:          newEvent(ports.EventCompanyCreated, duplicate.ID),
+			},
+		}
+	}
+
+	results, err := repo.ApplyBatch(ctx, domain.BatchAtomic, false, operations("created"))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.ErrorIs(t, results[0].Err, domain.ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, domain.ErrBatchAborted)
+	assert.ErrorAs(t, results[2].Err, &nameAlreadyTakenErr)
+
+	stored, err := repo.GetCompanyByID(ctx, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, existing, stored, "failed atomic batch has to be rolled back")
+	assert.Zero(t, drainOutbox(t, repo), "failed atomic batch mustn't store events")
+
+	results, err = repo.ApplyBatch(ctx, domain.BatchBestEffort, true, operations("created"))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, 2, results[1].Company.Version)
+	assert.ErrorAs(t, results[2].Err, &nameAlreadyTakenErr, "dry run has to check taken names")
+
+	stored, err = repo.GetCompanyByID(ctx, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, existing, stored, "dry run has to be rolled back")
+	_, err = repo.GetCompanyByID(ctx, results[0].Company.ID)
+	assert.ErrorAs(t, err, &companyNotFoundErr, "dry run has to be rolled back")
+	assert.Zero(t, drainOutbox(t, repo), "dry run mustn't store events")
+
+	results, err = repo.ApplyBatch(ctx, domain.BatchBestEffort, false, operations("renamed"))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, 1, results[0].Company.Version)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, "renamed", results[1].Company.Name)
+	assert.Equal(t, 2, results[1].Company.Version)
+	assert.ErrorAs(t, results[2].Err, &nameAlreadyTakenErr)
+	assert.Equal(t, 2, drainOutbox(t, repo), "failed operations mustn't store events")
+
+	stored, err = repo.GetCompanyByID(ctx, results[0].Company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, results[0].Company, stored)
+
+	results, err = repo.ApplyBatch(ctx, domain.BatchAtomic, false, []*ports.BatchOperation{{
+		BatchOperation: domain.BatchOperation{Operation: domain.OperationDelete, ID: existing.ID, Version: 2},
+		Event:          newEvent(ports.EventCompanyDeleted, existing.ID),
+	}})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+
+	_, err = repo.GetCompanyByID(ctx, existing.ID)
+	assert.ErrorAs(t, err, &companyNotFoundErr)
+}
+
+func testGetCompanyHistory(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	started := time.Now().Add(-time.Minute)
+	company := create(t, repo, "history")
+
+	employeeCnt := 20
+	require.NoError(t, repo.UpdateCompany(ctx, company.ID, 0, domain.CompanyPatch{EmployeeCnt: &employeeCnt}, newEvent(ports.EventCompanyUpdated, company.ID)))
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 0, newEvent(ports.EventCompanyDeleted, company.ID)))
+
+	restored, err := repo.RestoreCompany(ctx, company.ID, newEvent(ports.EventCompanyRestored, company.ID))
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 0, newEvent(ports.EventCompanyDeleted, company.ID)))
+	require.NoError(t, repo.PurgeCompany(ctx, company.ID, newEvent(ports.EventCompanyPurged, company.ID)))
+
+	history, err := repo.GetCompanyHistory(ctx, company.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 6)
+
+	updated := *company
+	updated.EmployeeCnt, updated.Version = employeeCnt, 2
+	deleted := *restored
+	deleted.Version++
+
+	// versions are the ones of the company, so they don't match positions once it is purged
+	want := []struct {
+		operation     string
+		version       int
+		before, after *domain.Company
+	}{
+		{domain.OperationCreate, company.Version, nil, company},
+		{domain.OperationUpdate, updated.Version, company, &updated},
+		{domain.OperationDelete, updated.Version + 1, &updated, nil},
+		{domain.OperationRestore, restored.Version, nil, restored},
+		{domain.OperationDelete, deleted.Version, restored, nil},
+		{domain.OperationPurge, deleted.Version, &deleted, nil},
+	}
+
+	for i, version := range history {
+		assert.Equal(t, want[i].version, version.Version, "version of %s", want[i].operation)
+		assert.Equal(t, want[i].operation, version.Operation)
+		assert.Equal(t, actor, version.Actor)
+		assert.True(t, version.ChangedAt.After(started), "changed_at has to be the time of the change")
+		assert.Equal(t, want[i].before, version.Before)
+		assert.Equal(t, want[i].after, version.After)
+		assert.Empty(t, version.Changes, "changes are computed by the service")
+	}
+
+	history, err = repo.GetCompanyHistory(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func testOutbox(t *testing.T, repo ports.Repository) {
+	ctx := context.Background()
+
+	companies := []*domain.Company{create(t, repo, "first"), create(t, repo, "second"), create(t, repo, "third")}
+
+	employeeCnt := 20
+	patch := domain.CompanyPatch{EmployeeCnt: &employeeCnt}
+	require.NoError(t, repo.UpdateCompany(newContext(), companies[0].ID, 0, patch, newEvent(ports.EventCompanyUpdated, companies[0].ID)))
+
+	messages, err := repo.FetchOutbox(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	for i, message := range messages {
+		assert.Equal(t, ports.EventCompanyCreated, message.Event.Name)
+		assert.Equal(t, producer, message.Event.Producer)
+		assert.Equal(t, companies[i].ID, message.Event.CompanyID, "messages have to be fetched in the order they were stored")
+		assert.Equal(t, &ports.CompanyCreatedData{ID: companies[i].ID}, message.Event.Data)
+		assert.Zero(t, message.Attempts)
+	}
+
+	assert.Less(t, messages[0].ID, messages[1].ID)
+
+	claimed, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "claimed messages and later messages of their companies mustn't be fetched")
+	assert.Equal(t, companies[2].ID, claimed[0].Event.CompanyID)
+
+	require.NoError(t, repo.RescheduleOutbox(ctx, messages[0].ID, time.Now().Add(time.Hour), "broker is down"))
+	require.NoError(t, repo.DeleteOutbox(ctx, messages[1].ID, claimed[0].ID))
+
+	postponed, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, postponed, "postponed messages and later messages of their companies mustn't be fetched")
+
+	require.NoError(t, repo.RescheduleOutbox(ctx, messages[0].ID, time.Now().Add(-time.Second), "broker is down"))
+
+	rescheduled, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, rescheduled, 1)
+	assert.Equal(t, messages[0].ID, rescheduled[0].ID)
+	assert.Equal(t, 2, rescheduled[0].Attempts)
+
+	require.NoError(t, repo.DeleteOutbox(ctx, rescheduled[0].ID))
+
+	updated, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, updated, 1, "later messages have to be fetched once earlier ones are delivered")
+	assert.Equal(t, ports.EventCompanyUpdated, updated[0].Event.Name)
+
+	require.NoError(t, repo.DeleteOutbox(ctx, updated[0].ID))
+	assert.Zero(t, drainOutbox(t, repo))
+}
+
+func testStoreOutbox(t *testing.T, repo ports.Repository) {
+	ctx := context.Background()
+	company := create(t, repo, "replayed")
+
+	replayed := []*ports.CompanyMutationEvent{
+		newEvent(ports.EventCompanyCreated, company.ID),
+		newEvent(ports.EventCompanyDeleted, company.ID),
+	}
+	require.NoError(t, repo.StoreOutbox(newContext(), replayed...))
+
+	var names []string
+
+	for {
+		messages, err := repo.FetchOutbox(ctx, 10)
+		require.NoError(t, err)
+
+		if len(messages) == 0 {
+			break
+		}
+
+		require.Len(t, messages, 1, "stored events have to be fetched one by one with the events of the company")
+		names = append(names, messages[0].Event.Name)
+		require.NoError(t, repo.DeleteOutbox(ctx, messages[0].ID))
+	}
+
+	assert.Equal(t, []string{ports.EventCompanyCreated, ports.EventCompanyCreated, ports.EventCompanyDeleted}, names)
+}
+
+// testConcurrentFetches checks that concurrent relays never fetch the same messages.
+func testConcurrentFetches(t *testing.T, repo ports.Repository) {
+	for i := 0; i < concurrency; i++ {
+		create(t, repo, fmt.Sprintf("company %d", i))
+	}
+
+	var (
+		mu      sync.Mutex
+		fetched = make(map[int64]int)
+	)
+
+	runConcurrently(func(i int) error {
+		messages, err := repo.FetchOutbox(context.Background(), 3)
+		assert.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, message := range messages {
+			fetched[message.ID]++
+		}
+
+		return err
+	})
+
+	for id, times := range fetched {
+		assert.Equal(t, 1, times, "message %d is fetched more than once", id)
+	}
+}
+
+// testConcurrentCreates checks that names stay unique when companies are created concurrently.
+func testConcurrentCreates(t *testing.T, repo ports.Repository) {
+	errs := runConcurrently(func(i int) error {
+		company := newCompany("concurrent")
+
+		return repo.CreateCompany(newContext(), company, newEvent(ports.EventCompanyCreated, company.ID))
+	})
+
+	created := 0
+
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+
+		assert.ErrorAs(t, err, &nameAlreadyTakenErr)
+	}
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, drainOutbox(t, repo))
+}
+
+// testConcurrentUpdates checks that concurrent updates are neither lost nor applied on top of a stale version.
+func testConcurrentUpdates(t *testing.T, repo ports.Repository) {
+	ctx := newContext()
+	company := create(t, repo, "concurrent")
+
+	errs := runConcurrently(func(i int) error {
+		description := fmt.Sprintf("update %d", i)
+		patch := domain.CompanyPatch{Description: &description}
+
+		return repo.UpdateCompany(newContext(), company.ID, 1, patch, newEvent(ports.EventCompanyUpdated, company.ID))
+	})
+
+	updated := 0
+
+	for _, err := range errs {
+		if err == nil {
+			updated++
+			continue
+		}
+
+		assert.ErrorAs(t, err, &versionMismatchErr)
+	}
+
+	assert.Equal(t, 1, updated, "only one update of the same version has to succeed")
+
+	runConcurrently(func(i int) error {
+		employeeCnt := i
+		patch := domain.CompanyPatch{EmployeeCnt: &employeeCnt}
+
+		err := repo.UpdateCompany(newContext(), company.ID, 0, patch, newEvent(ports.EventCompanyUpdated, company.ID))
+		assert.NoError(t, err)
+
+		return err
+	})
+
+	stored, err := repo.GetCompanyByID(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2+concurrency, stored.Version, "every unconditional update has to increment the version")
+
+	history, err := repo.GetCompanyHistory(ctx, company.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, stored.Version)
+	assert.Equal(t, stored.Version, drainOutbox(t, repo))
+}
+
+// runConcurrently calls fn from concurrency goroutines at once and returns the errors it returned.
+func runConcurrently(fn func(i int) error) []error {
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make([]error, concurrency)
+	)
+
+	for i := 0; i < concurrency; i++ {
+		i := i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	return errs
+}
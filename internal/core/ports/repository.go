@@ -2,19 +2,88 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
 	"github.com/google/uuid"
 )
 
+// BatchOperation is an operation of the batch together with the event it produces.
+type BatchOperation struct {
+	domain.BatchOperation
+	Event *CompanyMutationEvent
+}
+
+// Repository stores companies. Every mutation records a new company version in its history
+// and stores the provided event in the outbox within the same transaction, so both of them
+// exist if and only if the mutation is committed.
 type Repository interface {
 	Migrate() error
 	GetCompanyByID(ctx context.Context, id uuid.UUID) (*domain.Company, error)
-	CreateCompany(ctx context.Context, company *domain.Company) error
 
-	// UpdateCompany fetches updated fields and values provided in the fieldsToUpdate argument.
-	// Returns either user-friendly errors of types domain.CompanyNotFoundError and
-	// domain.NameAlreadyTakenError, or internal error.
-	UpdateCompany(ctx context.Context, id uuid.UUID, fieldsToUpdate map[string]any) error
-	DeleteCompany(ctx context.Context, id uuid.UUID) error
+	// ListCompanies returns at most params.Limit companies matching params.Filter,
+	// ordered by name and id and located after params.After when it is set.
+	ListCompanies(ctx context.Context, params domain.ListParams) ([]*domain.Company, error)
+	CreateCompany(ctx context.Context, company *domain.Company, event *CompanyMutationEvent) error
+
+	// UpdateCompany sets the fields changed by the patch and increments the company version.
+	// Unless version is zero, it has to match the current company version. Returns either
+	// user-friendly errors of types domain.CompanyNotFoundError, domain.NameAlreadyTakenError
+	// and domain.VersionMismatchError, or internal error.
+	UpdateCompany(
+		ctx context.Context,
+		id uuid.UUID,
+		version int,
+		patch domain.CompanyPatch,
+		event *CompanyMutationEvent,
+	) error
+
+	// ApplyBatch applies the operations within a single transaction and stores their events in the outbox.
+	// The first failed operation of an atomic batch rolls back the whole batch, and the rest of its operations
+	// result in domain.ErrBatchAborted. Failed operations of a best-effort batch are rolled back individually.
+	// Failures are reported in the results the same way single mutations report them, internal errors
+	// fail the whole batch. Dry-run batches are always rolled back, so their results only report whether
+	// the operations would succeed, including the uniqueness of the names.
+	ApplyBatch(
+		ctx context.Context,
+		mode string,
+		dryRun bool,
+		operations []*BatchOperation,
+	) ([]*domain.BatchResult, error)
+
+	// DeleteCompany marks the company as deleted, version is checked the same way UpdateCompany does.
+	// Deleted companies are treated as nonexistent by the rest of the methods,
+	// except RestoreCompany and PurgeCompany.
+	DeleteCompany(ctx context.Context, id uuid.UUID, version int, event *CompanyMutationEvent) error
+
+	// RestoreCompany reverts deletion of the company and returns it. Returns
+	// domain.CompanyNotFoundError if there is no such deleted company and domain.NameAlreadyTakenError
+	// if its name was taken after the deletion.
+	RestoreCompany(ctx context.Context, id uuid.UUID, event *CompanyMutationEvent) (*domain.Company, error)
+
+	// ListPurgeableCompanies returns at most limit ids of companies deleted before deletedBefore.
+	ListPurgeableCompanies(ctx context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error)
+
+	// PurgeCompany permanently removes the deleted company.
+	PurgeCompany(ctx context.Context, id uuid.UUID, event *CompanyMutationEvent) error
+
+	// GetCompanyHistory returns all recorded versions of the company ordered from the oldest one.
+	// Changes of the versions are left empty. Returns no versions for unknown companies.
+	GetCompanyHistory(ctx context.Context, id uuid.UUID) ([]*domain.CompanyVersion, error)
+
+	// StoreOutbox stores events which aren't produced by mutations, e.g. replayed ones, in the outbox
+	// within a single transaction, so they are relayed in order with the events of the mutations.
+	StoreOutbox(ctx context.Context, events ...*CompanyMutationEvent) error
+
+	// FetchOutbox returns at most limit outbox messages due for delivery in the order they were stored.
+	// Only the oldest message of every company is returned, so later events of the company wait until
+	// it's delivered. Returned messages are claimed by the caller for a while and aren't returned
+	// by other calls until they are delivered, rescheduled or the claim expires.
+	FetchOutbox(ctx context.Context, limit int) ([]*OutboxMessage, error)
+
+	// DeleteOutbox removes delivered messages from the outbox.
+	DeleteOutbox(ctx context.Context, ids ...int64) error
+
+	// RescheduleOutbox increments delivery attempts of the message and postpones its next attempt.
+	RescheduleOutbox(ctx context.Context, id int64, nextAttemptAt time.Time, reason string) error
 }
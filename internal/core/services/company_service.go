@@ -4,25 +4,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
+// CompanyService implements company use cases. Mutation events are passed to the repository,
+// which stores them in the outbox, and are published later by the OutboxRelay.
 type CompanyService struct {
-	repo         ports.Repository
-	eventsWriter ports.EventsWriter
-	appName      string
+	repo    ports.Repository
+	metrics ports.Metrics
+	logger  *zap.Logger
+	appName string
 }
 
-func NewCompanyService(appName string, repo ports.Repository, ew ports.EventsWriter) *CompanyService {
+func NewCompanyService(
+	appName string,
+	repo ports.Repository,
+	metrics ports.Metrics,
+	logger *zap.Logger,
+) *CompanyService {
 	return &CompanyService{
 		appName: appName,
 
-		repo:         repo,
-		eventsWriter: ew,
+		repo:    repo,
+		metrics: metrics,
+		logger:  logger.Named("company_service"),
 	}
 }
 
@@ -34,7 +44,7 @@ func (cs CompanyService) Get(ctx context.Context, id uuid.UUID) (*domain.Company
 			return nil, err
 		}
 
-		log.Printf("company service: get: %s", err.Error())
+		logging.FromContext(ctx, cs.logger).Error("get company", zap.Error(err))
 
 		return nil, domain.ErrInternalServer
 	}
@@ -42,6 +52,67 @@ func (cs CompanyService) Get(ctx context.Context, id uuid.UUID) (*domain.Company
 	return company, nil
 }
 
+// List returns a single page of companies. One extra company is requested from the
+// repository to find out whether the next page exists without an additional query.
+func (cs CompanyService) List(ctx context.Context, params domain.ListParams) (*domain.CompanyPage, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	limit := params.Limit
+	params.Limit++
+
+	companies, err := cs.repo.ListCompanies(ctx, params)
+	if err != nil {
+		logging.FromContext(ctx, cs.logger).Error("list companies", zap.Error(err))
+
+		return nil, domain.ErrInternalServer
+	}
+
+	page := &domain.CompanyPage{Companies: companies}
+	if len(companies) > limit {
+		page.Companies = companies[:limit]
+		page.NextCursor = domain.NewListCursor(companies[limit-1]).Encode()
+	}
+
+	return page, nil
+}
+
+// Export passes all companies matching the filter to fn page by page in the order of their names,
+// so they don't have to be loaded into memory at once. Errors returned by fn stop the export and are
+// passed through as is. Companies changed during the export might be either included or not.
+func (cs CompanyService) Export(
+	ctx context.Context,
+	filter domain.CompanyFilter,
+	fn func(companies []*domain.Company) error,
+) error {
+	params := domain.ListParams{Filter: filter, Limit: domain.ListMaxLimit}
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	for {
+		companies, err := cs.repo.ListCompanies(ctx, params)
+		if err != nil {
+			logging.FromContext(ctx, cs.logger).Error("export companies", zap.Error(err))
+
+			return domain.ErrInternalServer
+		}
+
+		if len(companies) > 0 {
+			if err := fn(companies); err != nil {
+				return err
+			}
+		}
+
+		if len(companies) < params.Limit {
+			return nil
+		}
+
+		params.After = domain.NewListCursor(companies[len(companies)-1])
+	}
+}
+
 func (cs CompanyService) Create(ctx context.Context, company *domain.Company) error {
 	if err := company.Validate(); err != nil {
 		return fmt.Errorf("validation error: %w", err)
@@ -49,141 +120,191 @@ func (cs CompanyService) Create(ctx context.Context, company *domain.Company) er
 
 	company.SetID()
 
-	err := cs.repo.CreateCompany(ctx, company)
+	err := cs.repo.CreateCompany(ctx, company, cs.createdEvent(ctx, company))
 	if err != nil {
 		var companyNameAlreadyTakenError *domain.NameAlreadyTakenError
 		if errors.As(err, &companyNameAlreadyTakenError) {
 			return err
 		}
 
-		log.Printf("company service: create: %s", err.Error())
+		logging.FromContext(ctx, cs.logger).Error("create company", zap.Error(err))
 
 		return domain.ErrInternalServer
 	}
 
-	event := ports.NewCompanyMutationEvent(
-		"CompanyCreated",
-		cs.appName,
-		company,
-	)
+	cs.metrics.CompanyMutated(domain.OperationCreate)
 
-	if err := cs.eventsWriter.Write(ctx, event); err != nil {
-		log.Printf("company service: create: send event: %s", err.Error())
+	return nil
+}
+
+func (cs CompanyService) Update(ctx context.Context, id uuid.UUID, version int, patch domain.CompanyPatch) error {
+	if err := patch.Validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
 	}
 
+	err := cs.repo.UpdateCompany(ctx, id, version, patch, cs.updatedEvent(ctx, id, patch))
+	if err != nil {
+		var (
+			companyNameAlreadyTakenError *domain.NameAlreadyTakenError
+			companyNotFoundErr           *domain.CompanyNotFoundError
+			versionMismatchErr           *domain.VersionMismatchError
+		)
+
+		if errors.As(err, &companyNotFoundErr) ||
+			errors.As(err, &companyNameAlreadyTakenError) ||
+			errors.As(err, &versionMismatchErr) {
+			return err
+		}
+
+		logging.FromContext(ctx, cs.logger).Error("update company", zap.Error(err))
+
+		return domain.ErrInternalServer
+	}
+
+	cs.metrics.CompanyMutated(domain.OperationUpdate)
+
 	return nil
 }
 
-// Update when using gin.Context's Bind method it's impossible to distinguish
-// skipped fields from fields intentionally set to zero value, thus
-// updating requires manual validation of the received fields.
-func (cs CompanyService) Update(ctx context.Context, id uuid.UUID, fieldsToUpdate map[string]any) error {
-	for field, val := range fieldsToUpdate {
-		switch field {
-		case "name":
-			if _, ok := val.(string); !ok {
-				return fmt.Errorf("unsupported type for field %s", field)
-			}
+func (cs CompanyService) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	err := cs.repo.DeleteCompany(ctx, id, version, cs.deletedEvent(ctx, id))
+	if err != nil {
+		var (
+			companyNotFoundErr *domain.CompanyNotFoundError
+			versionMismatchErr *domain.VersionMismatchError
+		)
 
-			if err := domain.ValidateName(val.(string)); err != nil {
-				return err
-			}
+		if errors.As(err, &companyNotFoundErr) || errors.As(err, &versionMismatchErr) {
+			return err
+		}
 
-		case "description":
-			if _, ok := val.(string); !ok {
-				return fmt.Errorf("unsupported type for field %s", field)
-			}
+		logging.FromContext(ctx, cs.logger).Error("delete company", zap.Error(err))
 
-			if err := domain.ValidateDescription(val.(string)); err != nil {
-				return err
-			}
+		return domain.ErrInternalServer
+	}
 
-		case "type":
-			if _, ok := val.(string); !ok {
-				return fmt.Errorf("unsupported type for field %s", field)
-			}
+	cs.metrics.CompanyMutated(domain.OperationDelete)
 
-			if err := domain.ValidateType(val.(string)); err != nil {
-				return err
-			}
+	return nil
+}
 
-		case "employee_cnt":
-			_, okInt := val.(int)
-			_, okFloat := val.(float64)
+// Batch validates the operations and passes the valid ones to the repository, which applies them
+// within a single transaction. Dry-run batches are rolled back by the repository, so they report
+// the same failures the batch would, including taken names, but don't change anything.
+func (cs CompanyService) Batch(ctx context.Context, batch domain.Batch) ([]*domain.BatchResult, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
 
-			if !okInt && !okFloat {
-				return fmt.Errorf("unsupported type for field %s", field)
-			}
+	results := make([]*domain.BatchResult, len(batch.Operations))
+	operations := make([]*ports.BatchOperation, 0, len(batch.Operations))
+	indexes := make([]int, 0, len(batch.Operations))
 
-			intVal := int(val.(float64))
-			if okFloat {
-				fieldsToUpdate[field] = intVal
-			}
+	for i, operation := range batch.Operations {
+		if err := operation.Validate(); err != nil {
+			results[i] = &domain.BatchResult{Err: fmt.Errorf("validation error: %w", err)}
+			continue
+		}
 
-			if err := domain.ValidateEmployeeCnt(intVal); err != nil {
-				return err
-			}
+		var event *ports.CompanyMutationEvent
+
+		switch operation.Operation {
+		case domain.OperationCreate:
+			company := *operation.Company
+			company.SetID()
+			operation.Company = &company
+			event = cs.createdEvent(ctx, operation.Company)
+		case domain.OperationUpdate:
+			event = cs.updatedEvent(ctx, operation.ID, operation.Patch)
+		case domain.OperationDelete:
+			event = cs.deletedEvent(ctx, operation.ID)
+		}
 
-		case "registered":
-			if _, ok := val.(bool); !ok {
-				return fmt.Errorf("unsupported type for field %s", field)
-			}
+		operations = append(operations, &ports.BatchOperation{BatchOperation: operation, Event: event})
+		indexes = append(indexes, i)
+	}
+
+	if len(operations) == 0 {
+		return results, nil
+	}
+
+	applied, err := cs.repo.ApplyBatch(ctx, batch.Mode, batch.DryRun, operations)
+	if err != nil {
+		logging.FromContext(ctx, cs.logger).Error("apply batch", zap.Error(err))
 
-		default:
-			delete(fieldsToUpdate, field)
+		return nil, domain.ErrInternalServer
+	}
+
+	for i, result := range applied {
+		results[indexes[i]] = result
+
+		if result.Err == nil && !batch.DryRun {
+			cs.metrics.CompanyMutated(operations[i].Operation)
 		}
 	}
 
-	err := cs.repo.UpdateCompany(ctx, id, fieldsToUpdate)
+	return results, nil
+}
+
+func (cs CompanyService) Restore(ctx context.Context, id uuid.UUID) (*domain.Company, error) {
+	event := ports.NewCompanyMutationEvent(ctx, cs.appName, id, &ports.CompanyRestoredData{ID: id})
+
+	company, err := cs.repo.RestoreCompany(ctx, id, event)
 	if err != nil {
 		var companyNameAlreadyTakenError *domain.NameAlreadyTakenError
 
 		var companyNotFoundErr *domain.CompanyNotFoundError
 		if errors.As(err, &companyNotFoundErr) || errors.As(err, &companyNameAlreadyTakenError) {
-			return err
+			return nil, err
 		}
 
-		log.Printf("company service: update: %s", err.Error())
+		logging.FromContext(ctx, cs.logger).Error("restore company", zap.Error(err))
 
-		return domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
 
-	fieldsToUpdate["id"] = id
-
-	event := ports.NewCompanyMutationEvent(
-		"CompanyUpdated",
-		cs.appName,
-		fieldsToUpdate,
-	)
-	if err := cs.eventsWriter.Write(ctx, event); err != nil {
-		log.Printf("company service: update: send event: %s", err.Error())
-	}
+	cs.metrics.CompanyMutated(domain.OperationRestore)
 
-	return nil
+	return company, nil
 }
 
-func (cs CompanyService) Delete(ctx context.Context, id uuid.UUID) error {
-	err := cs.repo.DeleteCompany(ctx, id)
+// History returns recorded versions of the company, including the deleted ones,
+// together with the changes each version introduced.
+func (cs CompanyService) History(ctx context.Context, id uuid.UUID) (*domain.CompanyHistory, error) {
+	versions, err := cs.repo.GetCompanyHistory(ctx, id)
 	if err != nil {
-		var companyNotFoundErr *domain.CompanyNotFoundError
+		logging.FromContext(ctx, cs.logger).Error("get company history", zap.Error(err))
 
-		if errors.As(err, &companyNotFoundErr) {
-			return err
-		}
-
-		log.Printf("company service: delete: %s", err.Error())
+		return nil, domain.ErrInternalServer
+	}
 
-		return domain.ErrInternalServer
+	if len(versions) == 0 {
+		return nil, domain.NewCompanyNotFoundError(id)
 	}
 
-	event := ports.NewCompanyMutationEvent(
-		"CompanyDeleted",
-		cs.appName,
-		map[string]string{"id": id.String()},
-	)
-	if err := cs.eventsWriter.Write(ctx, event); err != nil {
-		log.Printf("company service: delete: send event: %s", err.Error())
+	for _, version := range versions {
+		version.Changes = domain.DiffCompanies(version.Before, version.After)
 	}
 
-	return nil
+	return &domain.CompanyHistory{CompanyID: id, Versions: versions}, nil
+}
+
+func (cs CompanyService) createdEvent(ctx context.Context, company *domain.Company) *ports.CompanyMutationEvent {
+	// the event is created before the company is stored, which is when the first version is assigned to it
+	data := ports.NewCompanyCreatedData(company)
+	data.Version = 1
+
+	return ports.NewCompanyMutationEvent(ctx, cs.appName, company.ID, data)
+}
+
+func (cs CompanyService) updatedEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	patch domain.CompanyPatch,
+) *ports.CompanyMutationEvent {
+	return ports.NewCompanyMutationEvent(ctx, cs.appName, id, ports.NewCompanyUpdatedData(id, patch))
+}
+
+func (cs CompanyService) deletedEvent(ctx context.Context, id uuid.UUID) *ports.CompanyMutationEvent {
+	return ports.NewCompanyMutationEvent(ctx, cs.appName, id, &ports.CompanyDeletedData{ID: id})
 }
@@ -5,12 +5,15 @@ import (
 	"testing"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
-	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 var (
@@ -27,21 +30,23 @@ var (
 
 	CompanyNotFoundError         *domain.CompanyNotFoundError
 	CompanyNameAlreadyTakenError *domain.NameAlreadyTakenError
+	VersionMismatchError         *domain.VersionMismatchError
 )
 
 func TestCompanyService_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockEventsWriter := events.NewMockEventsWriter(ctrl)
 	mockRepo := repositories.NewMockRepository(ctrl)
-	mockRepo.EXPECT().GetCompanyById(context.Background(), Company.ID).Return(&Company, nil)
+	mockRepo.EXPECT().GetCompanyByID(context.Background(), Company.ID).Return(&Company, nil)
 
 	for _, id := range ids {
-		mockRepo.EXPECT().GetCompanyById(context.Background(), id).Return(nil, domain.NewCompanyNotFoundError(id))
+		mockRepo.EXPECT().GetCompanyByID(context.Background(), id).Return(nil, domain.NewCompanyNotFoundError(id))
 	}
 
-	companyService := services.NewCompanyService(appName, mockRepo, mockEventsWriter)
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
 
 	for _, id := range ids {
 		res, err := companyService.Get(context.Background(), id)
@@ -57,15 +62,16 @@ func TestCompanyService_Get(t *testing.T) {
 func TestCompanyService_Create(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
-	mockEventsWriter := events.NewMockEventsWriter(ctrl)
-	mockEventsWriter.EXPECT().Write(gomock.Any(), gomock.Any()).Return(nil)
 
 	mockRepo := repositories.NewMockRepository(ctrl)
-	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company).Return(nil)
-	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company).Return(domain.NewNameAlreadyTakenError(Company.Name))
-	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company).Return(domain.NewNameAlreadyTakenError(Company.Name))
+	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company, gomock.Any()).Return(domain.NewNameAlreadyTakenError(Company.Name))
+	mockRepo.EXPECT().CreateCompany(gomock.Any(), &Company, gomock.Any()).Return(domain.NewNameAlreadyTakenError(Company.Name))
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationCreate).Times(1)
 
-	companyService := services.NewCompanyService(appName, mockRepo, mockEventsWriter)
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
 
 	err := companyService.Create(context.Background(), &Company)
 	assert.NoError(t, err)
@@ -77,54 +83,263 @@ func TestCompanyService_Create(t *testing.T) {
 
 func TestCompanyService_Update(t *testing.T) {
 	var (
-		Update = map[string]any{
-			"name":         "new name",
-			"random field": 123,
-			"registered":   true,
-		}
-		InvalidUpdate = map[string]any{
-			"name":       123,
-			"registered": "no",
-		}
+		name, invalidName, invalidType = "new name", "na", "NonaProfit"
+		registered                     = true
+
+		Update        = domain.CompanyPatch{Name: &name, Registered: &registered}
+		InvalidUpdate = domain.CompanyPatch{Name: &invalidName, Type: &invalidType}
 	)
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockEventsWriter := events.NewMockEventsWriter(ctrl)
-	mockEventsWriter.EXPECT().Write(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-
 	mockRepo := repositories.NewMockRepository(ctrl)
-	mockRepo.EXPECT().UpdateCompany(gomock.Any(), Company.ID, Update).Return(nil)
-	mockRepo.EXPECT().UpdateCompany(gomock.Any(), ids[0], Update).Return(domain.NewCompanyNotFoundError(ids[0]))
-	mockRepo.EXPECT().UpdateCompany(gomock.Any(), ids[1], Update).Return(domain.NewNameAlreadyTakenError(Update["name"].(string)))
+	mockRepo.EXPECT().UpdateCompany(gomock.Any(), Company.ID, 0, Update, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().UpdateCompany(gomock.Any(), ids[0], 0, Update, gomock.Any()).Return(domain.NewCompanyNotFoundError(ids[0]))
+	mockRepo.EXPECT().UpdateCompany(gomock.Any(), ids[1], 0, Update, gomock.Any()).Return(domain.NewNameAlreadyTakenError(name))
+	mockRepo.EXPECT().UpdateCompany(gomock.Any(), ids[2], 1, Update, gomock.Any()).Return(domain.NewVersionMismatchError(ids[2], 1, 2))
 
-	companyService := services.NewCompanyService(appName, mockRepo, mockEventsWriter)
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationUpdate).Times(1)
 
-	err := companyService.Update(context.Background(), Company.ID, Update)
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+
+	err := companyService.Update(context.Background(), Company.ID, 0, Update)
 	assert.NoError(t, err)
-	err = companyService.Update(context.Background(), ids[0], Update)
+	err = companyService.Update(context.Background(), ids[0], 0, Update)
 	assert.ErrorAs(t, err, &CompanyNotFoundError)
-	err = companyService.Update(context.Background(), ids[1], Update)
+	err = companyService.Update(context.Background(), ids[1], 0, Update)
 	assert.ErrorAs(t, err, &CompanyNameAlreadyTakenError)
-	err = companyService.Update(context.Background(), Company.ID, InvalidUpdate)
-	assert.ErrorContains(t, err, "unsupported type")
+	err = companyService.Update(context.Background(), Company.ID, 0, InvalidUpdate)
+	errs, ok := domain.AsValidationErrors(err)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+	err = companyService.Update(context.Background(), ids[2], 1, Update)
+	assert.ErrorAs(t, err, &VersionMismatchError)
 }
 
 func TestCompanyService_Delete(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockEventsWriter := events.NewMockEventsWriter(ctrl)
-	mockEventsWriter.EXPECT().Write(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().DeleteCompany(gomock.Any(), Company.ID, 0, gomock.Any()).Return(nil)
+	mockRepo.EXPECT().DeleteCompany(gomock.Any(), Company.ID, 0, gomock.Any()).Return(domain.NewCompanyNotFoundError(Company.ID))
+	mockRepo.EXPECT().DeleteCompany(gomock.Any(), Company.ID, 3, gomock.Any()).Return(domain.NewVersionMismatchError(Company.ID, 3, 2))
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationDelete).Times(1)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+	err := companyService.Delete(context.Background(), Company.ID, 0)
+	assert.NoError(t, err)
+	err = companyService.Delete(context.Background(), Company.ID, 0)
+	assert.ErrorAs(t, err, &CompanyNotFoundError)
+	err = companyService.Delete(context.Background(), Company.ID, 3)
+	assert.ErrorAs(t, err, &VersionMismatchError)
+}
+
+func TestCompanyService_Batch(t *testing.T) {
+	var (
+		name        = "new name"
+		invalidName = "na"
+		invalid     = domain.Company{Name: "na", Type: "NonProfit"}
+		batch       = domain.Batch{
+			Mode: domain.BatchBestEffort,
+			Operations: []domain.BatchOperation{
+				{Operation: domain.OperationCreate, Company: &Company},
+				{Operation: domain.OperationUpdate, ID: ids[0], Patch: domain.CompanyPatch{Name: &invalidName}},
+				{Operation: domain.OperationUpdate, ID: ids[1], Patch: domain.CompanyPatch{Name: &name}},
+				{Operation: domain.OperationDelete, ID: ids[2], Version: 2},
+			},
+		}
+	)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().
+		ApplyBatch(gomock.Any(), domain.BatchBestEffort, false, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ bool, operations []*ports.BatchOperation) ([]*domain.BatchResult, error) {
+			require.Len(t, operations, 3)
+
+			assert.NotEqual(t, uuid.Nil, operations[0].Company.ID)
+			assert.Equal(t, operations[0].Company.ID, operations[0].Event.CompanyID)
+			assert.Equal(t, ids[1], operations[1].Event.CompanyID)
+			assert.Equal(t, "CompanyDeleted", operations[2].Event.Name)
+
+			return []*domain.BatchResult{
+				{Company: operations[0].Company},
+				{Err: domain.NewCompanyNotFoundError(ids[1])},
+				{},
+			}, nil
+		})
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationCreate).Times(1)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationDelete).Times(1)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+
+	results, err := companyService.Batch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, Company.Name, results[0].Company.Name)
+
+	errs, ok := domain.AsValidationErrors(results[1].Err)
+	assert.True(t, ok)
+	assert.Equal(t, "patch.name", errs[0].Field)
+	assert.ErrorAs(t, results[2].Err, &CompanyNotFoundError)
+	assert.NoError(t, results[3].Err)
+
+	// dry-run batches are rolled back by the repository and don't count as mutations
+	mockRepo.EXPECT().
+		ApplyBatch(gomock.Any(), domain.BatchBestEffort, true, gomock.Any()).
+		Return([]*domain.BatchResult{{}, {Err: domain.NewNameAlreadyTakenError(name)}, {}}, nil)
+
+	batch.DryRun = true
+	results, err = companyService.Batch(context.Background(), batch)
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.ErrorAs(t, results[2].Err, &CompanyNameAlreadyTakenError)
+	batch.DryRun = false
+
+	// invalid operations fail atomic batches before reaching the repository
+	batch.Mode = domain.BatchAtomic
+	batch.Operations[0].Company = &invalid
+	_, err = companyService.Batch(context.Background(), batch)
+	errs, ok = domain.AsValidationErrors(err)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+}
+
+func TestCompanyService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	companies := []*domain.Company{
+		{ID: ids[0], Name: "aaa"},
+		{ID: ids[1], Name: "bbb"},
+		{ID: ids[2], Name: "ccc"},
+	}
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().ListCompanies(gomock.Any(), domain.ListParams{Limit: 3}).Return(companies, nil)
+	mockRepo.EXPECT().ListCompanies(gomock.Any(), domain.ListParams{Limit: 4}).Return(companies, nil)
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+
+	page, err := companyService.List(context.Background(), domain.ListParams{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, companies[:2], page.Companies)
+
+	cursor, err := domain.DecodeListCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NewListCursor(companies[1]), cursor)
+
+	page, err = companyService.List(context.Background(), domain.ListParams{Limit: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, companies, page.Companies)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = companyService.List(context.Background(), domain.ListParams{Limit: 0})
+	assert.ErrorContains(t, err, "validation error")
+}
+
+func TestCompanyService_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	page := make([]*domain.Company, domain.ListMaxLimit)
+	for i := range page {
+		page[i] = &domain.Company{ID: uuid.New(), Name: "company"}
+	}
 
 	mockRepo := repositories.NewMockRepository(ctrl)
-	mockRepo.EXPECT().DeleteCompany(gomock.Any(), Company.ID).Return(nil)
-	mockRepo.EXPECT().DeleteCompany(gomock.Any(), Company.ID).Return(domain.NewCompanyNotFoundError(Company.ID))
+	gomock.InOrder(
+		mockRepo.EXPECT().ListCompanies(gomock.Any(), domain.ListParams{Limit: domain.ListMaxLimit}).Return(page, nil),
+		mockRepo.EXPECT().ListCompanies(gomock.Any(), domain.ListParams{
+			Limit: domain.ListMaxLimit,
+			After: domain.NewListCursor(page[len(page)-1]),
+		}).Return([]*domain.Company{&Company}, nil),
+	)
+
+	companyService := services.NewCompanyService(appName, mockRepo, metrics.NewMockMetrics(ctrl), zap.NewNop())
+
+	var exported int
 
-	companyService := services.NewCompanyService(appName, mockRepo, mockEventsWriter)
-	err := companyService.Delete(context.Background(), Company.ID)
+	err := companyService.Export(context.Background(), domain.CompanyFilter{}, func(companies []*domain.Company) error {
+		exported += len(companies)
+
+		return nil
+	})
 	assert.NoError(t, err)
-	err = companyService.Delete(context.Background(), Company.ID)
+	assert.Equal(t, domain.ListMaxLimit+1, exported)
+
+	minCnt, maxCnt := 2, 1
+	err = companyService.Export(
+		context.Background(),
+		domain.CompanyFilter{MinEmployeeCnt: &minCnt, MaxEmployeeCnt: &maxCnt},
+		func([]*domain.Company) error { return nil },
+	)
+	_, ok := domain.AsValidationErrors(err)
+	assert.True(t, ok)
+}
+
+func TestCompanyService_History(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	updated := Company
+	updated.Name = "new name"
+
+	versions := []*domain.CompanyVersion{
+		{Version: 1, Operation: domain.OperationCreate, After: &Company},
+		{Version: 2, Operation: domain.OperationUpdate, Before: &Company, After: &updated},
+	}
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetCompanyHistory(gomock.Any(), Company.ID).Return(versions, nil)
+	mockRepo.EXPECT().GetCompanyHistory(gomock.Any(), ids[0]).Return(nil, nil)
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+
+	history, err := companyService.History(context.Background(), Company.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, Company.ID, history.CompanyID)
+	assert.Len(t, history.Versions[0].Changes, 5)
+	assert.Equal(t, []domain.FieldChange{{Field: "name", Old: Company.Name, New: updated.Name}}, history.Versions[1].Changes)
+
+	_, err = companyService.History(context.Background(), ids[0])
 	assert.ErrorAs(t, err, &CompanyNotFoundError)
 }
+
+func TestCompanyService_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().RestoreCompany(gomock.Any(), Company.ID, gomock.Any()).Return(&Company, nil)
+	mockRepo.EXPECT().RestoreCompany(gomock.Any(), ids[0], gomock.Any()).Return(nil, domain.NewCompanyNotFoundError(ids[0]))
+	mockRepo.EXPECT().RestoreCompany(gomock.Any(), ids[1], gomock.Any()).Return(nil, domain.NewNameAlreadyTakenError(Company.Name))
+
+	mockMetrics := metrics.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().CompanyMutated(domain.OperationRestore).Times(1)
+
+	companyService := services.NewCompanyService(appName, mockRepo, mockMetrics, zap.NewNop())
+
+	res, err := companyService.Restore(context.Background(), Company.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &Company, res)
+	_, err = companyService.Restore(context.Background(), ids[0])
+	assert.ErrorAs(t, err, &CompanyNotFoundError)
+	_, err = companyService.Restore(context.Background(), ids[1])
+	assert.ErrorAs(t, err, &CompanyNameAlreadyTakenError)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// EventReplayer republishes mutation events of companies rebuilt from their recorded history,
+// so consumers which lost their state are able to catch up. Replayed events get new ids
+// and carry the time of the original change. They are stored in the outbox rather than written
+// directly, so the relay publishes them in order with the events of concurrent mutations.
+type EventReplayer struct {
+	companyService ports.CompanyService
+	repo           ports.Repository
+	appName        string
+}
+
+func NewEventReplayer(appName string, cs ports.CompanyService, repo ports.Repository) *EventReplayer {
+	return &EventReplayer{
+		companyService: cs,
+		repo:           repo,
+		appName:        appName,
+	}
+}
+
+// Replay stores all events of the company in the outbox in the order they were produced and returns their amount.
+func (r *EventReplayer) Replay(ctx context.Context, id uuid.UUID) (int, error) {
+	history, err := r.companyService.History(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("replay events: %w", err)
+	}
+
+	events := make([]*ports.CompanyMutationEvent, 0, len(history.Versions))
+
+	for _, version := range history.Versions {
+		event := r.versionEvent(ctx, id, version)
+		if event == nil {
+			continue
+		}
+
+		event.Time = version.ChangedAt
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := r.repo.StoreOutbox(ctx, events...); err != nil {
+		return 0, fmt.Errorf("replay events: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// versionEvent returns the event the version was produced by, or nil if the operation is unknown.
+func (r *EventReplayer) versionEvent(
+	ctx context.Context,
+	id uuid.UUID,
+	version *domain.CompanyVersion,
+) *ports.CompanyMutationEvent {
+	switch version.Operation {
+	case domain.OperationSnapshot, domain.OperationCreate:
+		return ports.NewCompanyMutationEvent(ctx, r.appName, id, ports.NewCompanyCreatedData(version.After))
+	case domain.OperationUpdate:
+		return ports.NewCompanyMutationEvent(ctx, r.appName, id, ports.NewCompanyUpdatedData(id, changedFields(version)))
+	case domain.OperationDelete:
+		return ports.NewCompanyMutationEvent(ctx, r.appName, id, &ports.CompanyDeletedData{ID: id})
+	case domain.OperationRestore:
+		return ports.NewCompanyMutationEvent(ctx, r.appName, id, &ports.CompanyRestoredData{ID: id})
+	case domain.OperationPurge:
+		return ports.NewCompanyMutationEvent(ctx, r.appName, id, &ports.CompanyPurgedData{ID: id})
+	default:
+		return nil
+	}
+}
+
+// changedFields returns the patch setting the fields changed by the version to their new values.
+func changedFields(version *domain.CompanyVersion) domain.CompanyPatch {
+	var patch domain.CompanyPatch
+
+	after := version.After
+	for _, change := range version.Changes {
+		switch change.Field {
+		case "name":
+			patch.Name = &after.Name
+		case "description":
+			patch.Description = &after.Description
+		case "employee_cnt":
+			patch.EmployeeCnt = &after.EmployeeCnt
+		case "registered":
+			patch.Registered = &after.Registered
+		case "type":
+			patch.Type = &after.Type
+		}
+	}
+
+	return patch
+}
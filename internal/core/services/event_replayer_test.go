@@ -0,0 +1,68 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEventReplayer_Replay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	updated := Company
+	updated.EmployeeCnt = 10
+	changedAt := time.Now().Add(-time.Hour).UTC()
+
+	versions := []*domain.CompanyVersion{
+		{Version: 1, Operation: domain.OperationCreate, ChangedAt: changedAt, After: &Company},
+		{Version: 2, Operation: domain.OperationUpdate, ChangedAt: changedAt, Before: &Company, After: &updated},
+		{Version: 3, Operation: domain.OperationDelete, ChangedAt: changedAt, Before: &updated},
+	}
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetCompanyHistory(gomock.Any(), Company.ID).Return(versions, nil)
+	mockRepo.EXPECT().GetCompanyHistory(gomock.Any(), ids[0]).Return(nil, nil)
+
+	mockRepo.EXPECT().
+		StoreOutbox(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, events ...*ports.CompanyMutationEvent) error {
+			names := make([]string, 0, len(events))
+
+			for _, event := range events {
+				assert.Equal(t, Company.ID, event.CompanyID)
+				assert.Equal(t, changedAt, event.Time)
+
+				names = append(names, event.Name)
+			}
+
+			assert.Equal(t, []string{"CompanyCreated", "CompanyUpdated", "CompanyDeleted"}, names)
+			employeeCnt := 10
+			assert.Equal(
+				t,
+				&ports.CompanyUpdatedData{ID: Company.ID, EmployeeCnt: &employeeCnt},
+				events[1].Data,
+			)
+
+			return nil
+		})
+
+	companyService := services.NewCompanyService(appName, mockRepo, metrics.NewMockMetrics(ctrl), zap.NewNop())
+	replayer := services.NewEventReplayer(appName, companyService, mockRepo)
+
+	replayed, err := replayer.Replay(context.Background(), Company.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, replayed)
+
+	_, err = replayer.Replay(context.Background(), ids[0])
+	assert.ErrorAs(t, err, &CompanyNotFoundError)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+// IdempotencyCleaner removes expired idempotency keys, which are otherwise removed only
+// when the same key is reused.
+type IdempotencyCleaner struct {
+	store    ports.IdempotencyStore
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+func NewIdempotencyCleaner(store ports.IdempotencyStore, interval time.Duration, logger *zap.Logger) *IdempotencyCleaner {
+	return &IdempotencyCleaner{
+		store:    store,
+		logger:   logger.Named("idempotency_cleaner"),
+		interval: interval,
+	}
+}
+
+// Run removes expired keys every interval until ctx is done.
+func (c *IdempotencyCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := c.store.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil {
+			c.logger.Error("delete expired keys", zap.Error(err))
+		}
+
+		if deleted > 0 {
+			c.logger.Info("deleted expired keys", zap.Int64("keys", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+// OutboxRelay publishes events stored in the outbox by the repository.
+// Messages are removed from the outbox only after they were written successfully, so every
+// event is delivered at least once. Events of a single company are always written in the
+// order they were stored: if an event can't be delivered, later events of the same company
+// wait until it is. Fetched messages are claimed, so relays of several replicas share the outbox.
+type OutboxRelay struct {
+	repo         ports.Repository
+	eventsWriter ports.EventsWriter
+	logger       *zap.Logger
+
+	interval   time.Duration
+	batchSize  int
+	maxBackoff time.Duration
+}
+
+func NewOutboxRelay(
+	repo ports.Repository,
+	ew ports.EventsWriter,
+	interval time.Duration,
+	batchSize int,
+	maxBackoff time.Duration,
+	logger *zap.Logger,
+) *OutboxRelay {
+	return &OutboxRelay{
+		repo:         repo,
+		eventsWriter: ew,
+		logger:       logger.Named("outbox_relay"),
+
+		interval:   interval,
+		batchSize:  batchSize,
+		maxBackoff: maxBackoff,
+	}
+}
+
+// Run relays the outbox every interval until ctx is done. Delivered batches are followed by the next one
+// immediately, so the backlog is drained quickly, even though a batch holds a single event of every company.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		delivered, err := r.Flush(ctx)
+		if err != nil {
+			r.logger.Error("flush outbox", zap.Error(err))
+		}
+
+		if err == nil && delivered > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes all outbox messages which are due for delivery, it's meant to be called on shutdown
+// after Run has returned, so events of the last handled requests aren't left until the next start.
+func (r *OutboxRelay) Drain(ctx context.Context) error {
+	for {
+		delivered, err := r.Flush(ctx)
+		if err != nil {
+			return err
+		}
+
+		if delivered == 0 {
+			return nil
+		}
+	}
+}
+
+// Flush publishes a single batch of outbox messages which are due for delivery
+// and returns the amount of delivered messages.
+func (r *OutboxRelay) Flush(ctx context.Context) (int, error) {
+	messages, err := r.repo.FetchOutbox(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(messages))
+	data := make([]any, 0, len(messages))
+
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		data = append(data, message.Event)
+	}
+
+	if writeErr := r.eventsWriter.Write(ctx, data...); writeErr != nil {
+		r.logger.Warn("write events", zap.Int("events", len(messages)), zap.Error(writeErr))
+
+		now := time.Now()
+
+		for _, message := range messages {
+			nextAttemptAt := now.Add(r.backoff(message.Attempts))
+			if err := r.repo.RescheduleOutbox(ctx, message.ID, nextAttemptAt, writeErr.Error()); err != nil {
+				return 0, err
+			}
+		}
+
+		return 0, nil
+	}
+
+	if err := r.repo.DeleteOutbox(ctx, ids...); err != nil {
+		return 0, err
+	}
+
+	return len(messages), nil
+}
+
+// backoff returns exponentially growing delay before the next delivery attempt.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.interval
+	for i := 0; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxBackoff {
+		return r.maxBackoff
+	}
+
+	return delay
+}
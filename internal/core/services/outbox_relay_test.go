@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newOutboxMessage(id int64, companyIdx int) *ports.OutboxMessage {
+	return &ports.OutboxMessage{
+		ID: id,
+		Event: ports.NewCompanyMutationEvent(
+			context.Background(),
+			appName,
+			ids[companyIdx],
+			&ports.CompanyUpdatedData{ID: ids[companyIdx]},
+		),
+	}
+}
+
+func TestOutboxRelay_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messages := []*ports.OutboxMessage{newOutboxMessage(1, 0), newOutboxMessage(3, 1), newOutboxMessage(5, 2)}
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockEventsWriter := events.NewMockEventsWriter(ctrl)
+
+	gomock.InOrder(
+		mockRepo.EXPECT().FetchOutbox(gomock.Any(), 10).Return(messages, nil),
+		mockEventsWriter.EXPECT().
+			Write(gomock.Any(), messages[0].Event, messages[1].Event, messages[2].Event).
+			Return(nil),
+		mockRepo.EXPECT().DeleteOutbox(gomock.Any(), int64(1), int64(3), int64(5)).Return(nil),
+	)
+
+	relay := services.NewOutboxRelay(mockRepo, mockEventsWriter, time.Second, 10, time.Minute, zap.NewNop())
+
+	delivered, err := relay.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+}
+
+func TestOutboxRelay_FlushWriteError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messages := []*ports.OutboxMessage{newOutboxMessage(1, 0), newOutboxMessage(2, 1)}
+	messages[1].Attempts = 10
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockEventsWriter := events.NewMockEventsWriter(ctrl)
+
+	mockRepo.EXPECT().FetchOutbox(gomock.Any(), 10).Return(messages, nil)
+	mockEventsWriter.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("broker is down"))
+
+	// the delay grows with the amount of attempts, but never exceeds the max backoff
+	mockRepo.EXPECT().
+		RescheduleOutbox(gomock.Any(), int64(1), gomock.Any(), "broker is down").
+		DoAndReturn(func(_ context.Context, _ int64, nextAttemptAt time.Time, _ string) error {
+			assert.WithinDuration(t, time.Now().Add(time.Second), nextAttemptAt, 500*time.Millisecond)
+			return nil
+		})
+	mockRepo.EXPECT().
+		RescheduleOutbox(gomock.Any(), int64(2), gomock.Any(), "broker is down").
+		DoAndReturn(func(_ context.Context, _ int64, nextAttemptAt time.Time, _ string) error {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), nextAttemptAt, 500*time.Millisecond)
+			return nil
+		})
+
+	relay := services.NewOutboxRelay(mockRepo, mockEventsWriter, time.Second, 10, time.Minute, zap.NewNop())
+
+	delivered, err := relay.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestOutboxRelay_Drain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	first := []*ports.OutboxMessage{newOutboxMessage(1, 0), newOutboxMessage(2, 1)}
+	second := []*ports.OutboxMessage{newOutboxMessage(3, 0)}
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockEventsWriter := events.NewMockEventsWriter(ctrl)
+
+	// batches are flushed until there is nothing left, later events of a company are fetched by the next batch
+	gomock.InOrder(
+		mockRepo.EXPECT().FetchOutbox(gomock.Any(), 2).Return(first, nil),
+		mockEventsWriter.EXPECT().Write(gomock.Any(), first[0].Event, first[1].Event).Return(nil),
+		mockRepo.EXPECT().DeleteOutbox(gomock.Any(), int64(1), int64(2)).Return(nil),
+		mockRepo.EXPECT().FetchOutbox(gomock.Any(), 2).Return(second, nil),
+		mockEventsWriter.EXPECT().Write(gomock.Any(), second[0].Event).Return(nil),
+		mockRepo.EXPECT().DeleteOutbox(gomock.Any(), int64(3)).Return(nil),
+		mockRepo.EXPECT().FetchOutbox(gomock.Any(), 2).Return(nil, nil),
+	)
+
+	relay := services.NewOutboxRelay(mockRepo, mockEventsWriter, time.Second, 2, time.Minute, zap.NewNop())
+
+	assert.NoError(t, relay.Drain(context.Background()))
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"go.uber.org/zap"
+)
+
+const (
+	purgeJobActor     = "system:purge-job"
+	purgeJobBatchSize = 100
+)
+
+// PurgeJob permanently removes companies which were deleted more than retention ago.
+type PurgeJob struct {
+	repo    ports.Repository
+	logger  *zap.Logger
+	appName string
+
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurgeJob(
+	appName string,
+	repo ports.Repository,
+	retention, interval time.Duration,
+	logger *zap.Logger,
+) *PurgeJob {
+	return &PurgeJob{
+		appName: appName,
+		repo:    repo,
+		logger:  logger.Named("purge_job"),
+
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges companies every interval until ctx is done.
+func (j *PurgeJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := j.Purge(ctx)
+		if err != nil {
+			j.logger.Error("purge companies", zap.Error(err))
+		}
+
+		if purged > 0 {
+			j.logger.Info("purged companies", zap.Int("companies", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes all companies deleted before the retention period and returns their amount.
+// Every purged company emits CompanyPurged event.
+func (j *PurgeJob) Purge(ctx context.Context) (int, error) {
+	ctx = domain.WithActor(ctx, purgeJobActor)
+	deletedBefore := time.Now().Add(-j.retention)
+	purged := 0
+
+	for {
+		ids, err := j.repo.ListPurgeableCompanies(ctx, deletedBefore, purgeJobBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, id := range ids {
+			event := ports.NewCompanyMutationEvent(ctx, j.appName, id, &ports.CompanyPurgedData{ID: id})
+
+			err := j.repo.PurgeCompany(ctx, id, event)
+			if err != nil {
+				// the company has been restored or purged concurrently
+				var companyNotFoundErr *domain.CompanyNotFoundError
+				if errors.As(err, &companyNotFoundErr) {
+					continue
+				}
+
+				return purged, err
+			}
+
+			purged++
+		}
+
+		if len(ids) < purgeJobBatchSize {
+			return purged, nil
+		}
+	}
+}
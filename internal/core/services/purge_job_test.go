@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPurgeJob_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().
+		ListPurgeableCompanies(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, deletedBefore time.Time, _ int) ([]uuid.UUID, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Hour), deletedBefore, time.Second)
+			return ids[:], nil
+		})
+
+	expectPurge := func(id uuid.UUID, err error) {
+		mockRepo.EXPECT().
+			PurgeCompany(gomock.Any(), id, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ uuid.UUID, event *ports.CompanyMutationEvent) error {
+				assert.Equal(t, "CompanyPurged", event.Name)
+				assert.Equal(t, id, event.CompanyID)
+				assert.NotEmpty(t, domain.ActorFromContext(ctx))
+
+				return err
+			})
+	}
+
+	expectPurge(ids[0], nil)
+	expectPurge(ids[1], domain.NewCompanyNotFoundError(ids[1]))
+	expectPurge(ids[2], nil)
+
+	purgeJob := services.NewPurgeJob(appName, mockRepo, time.Hour, time.Hour, zap.NewNop())
+
+	purged, err := purgeJob.Purge(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+}
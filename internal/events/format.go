@@ -0,0 +1,162 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/eventschema"
+	"github.com/segmentio/kafka-go"
+)
+
+// Format is the way events are encoded into Kafka messages.
+type Format string
+
+const (
+	// FormatNative encodes events as JSON of ports.CompanyMutationEvent.
+	FormatNative Format = "native"
+	// FormatCloudEventsStructured encodes events as JSON of CloudEvent, the message carries
+	// the whole event in the structured content mode of the CloudEvents Kafka protocol binding.
+	FormatCloudEventsStructured Format = "cloudevents-structured"
+	// FormatCloudEventsBinary encodes payloads of events as JSON and passes CloudEvents attributes
+	// in ce_* headers, as defined by the binary content mode of the CloudEvents Kafka protocol binding.
+	FormatCloudEventsBinary Format = "cloudevents-binary"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	jsonContentType        = "application/json"
+	cloudEventsContentType = "application/cloudevents+json; charset=UTF-8"
+
+	contentTypeHeader = "content-type"
+	// attributes of the events are passed in the headers with the prefix in the binary content mode
+	cloudEventsHeaderPrefix = "ce_"
+)
+
+// CloudEvent is the company mutation event in the CloudEvents format: its source is the app which produced it,
+// its type is the event name and its subject is the company id. Payload is described by the schema referenced
+// by DataSchema, the id of the request which produced the event is passed in the requestid extension attribute.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema"`
+	RequestID       string          `json:"requestid,omitempty"`
+	Data            ports.EventData `json:"data"`
+}
+
+func NewCloudEvent(event *ports.CompanyMutationEvent) *CloudEvent {
+	return &CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              event.ID.String(),
+		Source:          event.Producer,
+		Type:            event.Name,
+		Subject:         event.CompanyID.String(),
+		Time:            event.Time,
+		DataContentType: jsonContentType,
+		DataSchema:      eventschema.ID(event.Name, event.SchemaVersion),
+		RequestID:       event.RequestID,
+		Data:            event.Data,
+	}
+}
+
+// BinaryHeaders returns the context attributes of the event as headers of the message in the binary content mode.
+func (ce *CloudEvent) BinaryHeaders() map[string]string {
+	headers := map[string]string{
+		contentTypeHeader:                       ce.DataContentType,
+		cloudEventsHeaderPrefix + "specversion": ce.SpecVersion,
+		cloudEventsHeaderPrefix + "id":          ce.ID,
+		cloudEventsHeaderPrefix + "source":      ce.Source,
+		cloudEventsHeaderPrefix + "type":        ce.Type,
+		cloudEventsHeaderPrefix + "subject":     ce.Subject,
+		cloudEventsHeaderPrefix + "time":        ce.Time.UTC().Format(time.RFC3339Nano),
+		cloudEventsHeaderPrefix + "dataschema":  ce.DataSchema,
+	}
+
+	if ce.RequestID != "" {
+		headers[cloudEventsHeaderPrefix+"requestid"] = ce.RequestID
+	}
+
+	return headers
+}
+
+// Validate checks that the format is supported.
+func (f Format) Validate() error {
+	switch f {
+	case FormatNative, FormatCloudEventsStructured, FormatCloudEventsBinary:
+		return nil
+	default:
+		return fmt.Errorf("unsupported events format \"%s\"", f)
+	}
+}
+
+// Encode returns the message carrying the data. Only company mutation events can be encoded
+// in the CloudEvents formats, while any data can be encoded in the native one.
+func (f Format) Encode(data any) (kafka.Message, error) {
+	var message kafka.Message
+
+	if keyed, ok := data.(keyer); ok {
+		message.Key = keyed.Key()
+	}
+
+	if f == FormatNative {
+		value, err := json.Marshal(data)
+		if err != nil {
+			return message, fmt.Errorf("encode event: %w", err)
+		}
+
+		message.Value = value
+
+		return message, nil
+	}
+
+	event, ok := data.(*ports.CompanyMutationEvent)
+	if !ok {
+		return message, fmt.Errorf("encode event: %T can't be encoded in %s format", data, f)
+	}
+
+	cloudEvent := NewCloudEvent(event)
+
+	var (
+		value   any
+		headers map[string]string
+	)
+
+	switch f {
+	case FormatCloudEventsStructured:
+		value = cloudEvent
+		headers = map[string]string{contentTypeHeader: cloudEventsContentType}
+	case FormatCloudEventsBinary:
+		value = cloudEvent.Data
+		headers = cloudEvent.BinaryHeaders()
+	default:
+		return message, fmt.Errorf("encode event: %w", f.Validate())
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return message, fmt.Errorf("encode event: %w", err)
+	}
+
+	message.Value = encoded
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	// headers are sorted, so messages of the same event are identical
+	sort.Strings(names)
+
+	for _, name := range names {
+		message.Headers = append(message.Headers, kafka.Header{Key: name, Value: []byte(headers[name])})
+	}
+
+	return message, nil
+}
@@ -0,0 +1,105 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompanyEvent() *ports.CompanyMutationEvent {
+	companyID := uuid.New()
+	event := ports.NewCompanyMutationEvent(
+		domain.WithRequestID(context.Background(), "request-id"),
+		"companies",
+		companyID,
+		&ports.CompanyDeletedData{ID: companyID},
+	)
+	event.Time = time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	return event
+}
+
+func headers(message kafka.Message) map[string]string {
+	values := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		values[header.Key] = string(header.Value)
+	}
+
+	return values
+}
+
+func TestFormat_Encode(t *testing.T) {
+	event := newCompanyEvent()
+
+	t.Run("native", func(t *testing.T) {
+		message, err := events.FormatNative.Encode(event)
+		require.NoError(t, err)
+
+		assert.Equal(t, []byte(event.CompanyID.String()), message.Key)
+		assert.Empty(t, message.Headers)
+
+		decoded := new(ports.CompanyMutationEvent)
+		require.NoError(t, json.Unmarshal(message.Value, decoded))
+		assert.Equal(t, event.ID, decoded.ID)
+		assert.Equal(t, event.Data, decoded.Data)
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		message, err := events.FormatCloudEventsStructured.Encode(event)
+		require.NoError(t, err)
+
+		assert.Equal(t, []byte(event.CompanyID.String()), message.Key)
+		assert.Equal(t, map[string]string{"content-type": "application/cloudevents+json; charset=UTF-8"}, headers(message))
+		assert.JSONEq(t, `{
+			"specversion": "1.0",
+			"id": "`+event.ID.String()+`",
+			"source": "companies",
+			"type": "CompanyDeleted",
+			"subject": "`+event.CompanyID.String()+`",
+			"time": "2023-05-01T12:30:00Z",
+			"datacontenttype": "application/json",
+			"dataschema": "urn:event-type:companies:CompanyDeleted:v1",
+			"requestid": "request-id",
+			"data": {"id": "`+event.CompanyID.String()+`"}
+		}`, string(message.Value))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		message, err := events.FormatCloudEventsBinary.Encode(event)
+		require.NoError(t, err)
+
+		assert.Equal(t, []byte(event.CompanyID.String()), message.Key)
+		assert.Equal(t, map[string]string{
+			"content-type":   "application/json",
+			"ce_specversion": "1.0",
+			"ce_id":          event.ID.String(),
+			"ce_source":      "companies",
+			"ce_type":        "CompanyDeleted",
+			"ce_subject":     event.CompanyID.String(),
+			"ce_time":        "2023-05-01T12:30:00Z",
+			"ce_dataschema":  "urn:event-type:companies:CompanyDeleted:v1",
+			"ce_requestid":   "request-id",
+		}, headers(message))
+		assert.JSONEq(t, `{"id": "`+event.CompanyID.String()+`"}`, string(message.Value))
+	})
+
+	t.Run("unsupported data", func(t *testing.T) {
+		_, err := events.FormatCloudEventsBinary.Encode(map[string]string{"id": "1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := events.Format("avro").Encode(event)
+		assert.Error(t, err)
+		assert.Error(t, events.Format("avro").Validate())
+	})
+}
@@ -2,19 +2,40 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// keyer is implemented by events which have to be partitioned by key.
+type keyer interface {
+	Key() []byte
+}
+
+// headerer is implemented by events carrying trace context headers of the operation which produced them.
+type headerer interface {
+	Headers() map[string]string
+}
+
 type KafkaWriter struct {
 	brokers []string
 	topic   string
+	format  Format
 	writer  *kafka.Writer
+	tracer  trace.Tracer
 }
 
-func NewKafkaWriter(brokers []string, topic string) (*KafkaWriter, error) {
+// NewKafkaWriter returns the writer publishing events to the topic in the format.
+func NewKafkaWriter(brokers []string, topic string, format Format) (*KafkaWriter, error) {
+	if err := format.Validate(); err != nil {
+		return nil, fmt.Errorf("new kafka writer: %w", err)
+	}
+
 	_, err := kafka.DialLeader(context.Background(), "tcp", brokers[0], topic, 0)
 	if err != nil {
 		return nil, fmt.Errorf("new kafka writer: error dialing: %w", err)
@@ -23,31 +44,103 @@ func NewKafkaWriter(brokers []string, topic string) (*KafkaWriter, error) {
 	return &KafkaWriter{
 		brokers: brokers,
 		topic:   topic,
+		format:  format,
 		writer: &kafka.Writer{
 			Addr:     kafka.TCP(brokers...),
 			Topic:    topic,
-			Balancer: &kafka.LeastBytes{},
+			Balancer: &kafka.Hash{},
 		},
+		tracer: otel.Tracer("github.com/dimaglushkov/epam-xm-test-assignment/internal/events"),
 	}, nil
 }
 
+// Write publishes data encoded in the format of the writer as a single batch. Every message gets
+// a producer span, which continues the trace from the data headers, if there are any, and is propagated
+// in the message headers.
 func (kw *KafkaWriter) Write(ctx context.Context, data ...any) error {
 	messages := make([]kafka.Message, 0, len(data))
+	spans := make([]trace.Span, 0, len(data))
+
+	defer func() {
+		for _, span := range spans {
+			span.End()
+		}
+	}()
 
 	for _, d := range data {
-		serializedData, err := json.Marshal(d)
+		message, err := kw.format.Encode(d)
 		if err != nil {
 			return err
 		}
 
-		messages = append(messages, kafka.Message{Value: serializedData})
+		spanCtx := ctx
+		if headered, ok := d.(headerer); ok {
+			spanCtx = tracing.Extract(ctx, headered.Headers())
+		}
+
+		spanCtx, span := kw.tracer.Start(spanCtx, kw.topic+" publish",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				semconv.MessagingSystem("kafka"),
+				semconv.MessagingDestinationName(kw.topic),
+				semconv.MessagingOperationPublish,
+			),
+		)
+		spans = append(spans, span)
+
+		for name, value := range tracing.Inject(spanCtx) {
+			message.Headers = append(message.Headers, kafka.Header{Key: name, Value: []byte(value)})
+		}
+
+		messages = append(messages, message)
 	}
 
 	err := kw.writer.WriteMessages(ctx, messages...)
+	if err != nil {
+		for _, span := range spans {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
 
 	return err
 }
 
-func (kw *KafkaWriter) Close() {
-	kw.writer.Close()
+// Ping checks that at least one of the brokers is reachable and knows the topic.
+func (kw *KafkaWriter) Ping(ctx context.Context) error {
+	var err error
+
+	for _, broker := range kw.brokers {
+		if err = kw.ping(ctx, broker); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("ping: %w", err)
+}
+
+func (kw *KafkaWriter) ping(ctx context.Context, broker string) error {
+	conn, err := kafka.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return fmt.Errorf("error dialing %s: %w", broker, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.ReadPartitions(kw.topic); err != nil {
+		return fmt.Errorf("error reading partitions of %s from %s: %w", kw.topic, broker, err)
+	}
+
+	return nil
+}
+
+func (kw *KafkaWriter) Close() error {
+	if err := kw.writer.Close(); err != nil {
+		return fmt.Errorf("close kafka writer: %w", err)
+	}
+
+	return nil
 }
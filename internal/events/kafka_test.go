@@ -0,0 +1,140 @@
+package events_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports/portstest"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBrokersEnv lists comma separated brokers the Kafka conformance suite runs against,
+// every test creates its own topic.
+const testBrokersEnv = "TEST_KAFKA_BROKERS"
+
+// publishTimeout limits waiting for events published by the writer.
+const publishTimeout = 30 * time.Second
+
+func TestKafkaWriter_Conformance(t *testing.T) {
+	brokers := os.Getenv(testBrokersEnv)
+	if brokers == "" {
+		t.Skipf("%s isn't set", testBrokersEnv)
+	}
+
+	portstest.RunEventsWriter(t, func(t *testing.T) *portstest.EventsHarness {
+		topic := "conformance-" + uuid.NewString()
+		createTopic(t, strings.Split(brokers, ",")[0], topic)
+
+		// the writer dials the partition leader, which is elected shortly after the topic is created
+		var writer *events.KafkaWriter
+
+		require.Eventually(t, func() bool {
+			var err error
+			writer, err = events.NewKafkaWriter(strings.Split(brokers, ","), topic, events.FormatNative)
+
+			return err == nil
+		}, publishTimeout, 100*time.Millisecond)
+
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     strings.Split(brokers, ","),
+			Topic:       topic,
+			StartOffset: kafka.FirstOffset,
+		})
+		t.Cleanup(func() {
+			_ = reader.Close()
+		})
+
+		var published [][]byte
+
+		return &portstest.EventsHarness{
+			Writer: writer,
+			Published: func(t *testing.T, n int) [][]byte {
+				ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+				defer cancel()
+
+				for len(published) < n {
+					message, err := reader.ReadMessage(ctx)
+					require.NoError(t, err)
+
+					published = append(published, message.Value)
+				}
+
+				return published
+			},
+		}
+	})
+}
+
+// createTopic creates the topic with a single partition, so the order of all published events is observable.
+func createTopic(t *testing.T, broker, topic string) {
+	t.Helper()
+
+	conn, err := kafka.Dial("tcp", broker)
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	require.NoError(t, err)
+
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	require.NoError(t, err)
+
+	defer controllerConn.Close()
+
+	require.NoError(t, controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     1,
+		ReplicationFactor: 1,
+	}))
+}
+
+func TestKafkaWriter_CloudEventsBinary(t *testing.T) {
+	brokers := os.Getenv(testBrokersEnv)
+	if brokers == "" {
+		t.Skipf("%s isn't set", testBrokersEnv)
+	}
+
+	topic := "cloudevents-" + uuid.NewString()
+	createTopic(t, strings.Split(brokers, ",")[0], topic)
+
+	var writer *events.KafkaWriter
+
+	require.Eventually(t, func() bool {
+		var err error
+		writer, err = events.NewKafkaWriter(strings.Split(brokers, ","), topic, events.FormatCloudEventsBinary)
+
+		return err == nil
+	}, publishTimeout, 100*time.Millisecond)
+
+	event := newCompanyEvent()
+	require.NoError(t, writer.Write(context.Background(), event))
+	require.NoError(t, writer.Close())
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     strings.Split(brokers, ","),
+		Topic:       topic,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	message, err := reader.ReadMessage(ctx)
+	require.NoError(t, err)
+
+	published := headers(message)
+	assert.Equal(t, event.ID.String(), published["ce_id"])
+	assert.Equal(t, "CompanyDeleted", published["ce_type"])
+	assert.JSONEq(t, `{"id": "`+event.CompanyID.String()+`"}`, string(message.Value))
+}
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryWriter records written events instead of publishing them, so the app is able to run without
+// a broker and tests are able to check emitted events.
+type MemoryWriter struct {
+	mu     sync.Mutex
+	events []any
+	limit  int
+}
+
+// NewMemoryWriter returns writer keeping at most limit latest events, zero limit keeps all of them.
+func NewMemoryWriter(limit int) *MemoryWriter {
+	return &MemoryWriter{limit: limit}
+}
+
+func (mw *MemoryWriter) Write(_ context.Context, data ...any) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.events = append(mw.events, data...)
+	if mw.limit > 0 && len(mw.events) > mw.limit {
+		mw.events = append([]any(nil), mw.events[len(mw.events)-mw.limit:]...)
+	}
+
+	return nil
+}
+
+// Events returns recorded events in the order they were written.
+func (mw *MemoryWriter) Events() []any {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	return append([]any(nil), mw.events...)
+}
+
+// Reset removes recorded events.
+func (mw *MemoryWriter) Reset() {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.events = nil
+}
+
+// Ping always succeeds, there is no broker to reach.
+func (mw *MemoryWriter) Ping(context.Context) error {
+	return nil
+}
+
+func (mw *MemoryWriter) Close() error {
+	return nil
+}
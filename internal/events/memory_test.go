@@ -0,0 +1,46 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports/portstest"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryWriter_Conformance(t *testing.T) {
+	portstest.RunEventsWriter(t, func(t *testing.T) *portstest.EventsHarness {
+		writer := events.NewMemoryWriter(0)
+
+		return &portstest.EventsHarness{
+			Writer: writer,
+			Published: func(t *testing.T, n int) [][]byte {
+				recorded := writer.Events()
+				payloads := make([][]byte, 0, len(recorded))
+
+				for _, event := range recorded {
+					payload, err := json.Marshal(event)
+					require.NoError(t, err)
+
+					payloads = append(payloads, payload)
+				}
+
+				return payloads
+			},
+		}
+	})
+}
+
+func TestMemoryWriter_Limit(t *testing.T) {
+	writer := events.NewMemoryWriter(2)
+
+	require.NoError(t, writer.Write(context.Background(), 1, 2))
+	require.NoError(t, writer.Write(context.Background(), 3))
+	assert.Equal(t, []any{2, 3}, writer.Events())
+
+	writer.Reset()
+	assert.Empty(t, writer.Events())
+}
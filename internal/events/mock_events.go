@@ -36,9 +36,12 @@ func (m *MockEventsWriter) EXPECT() *MockEventsWriterMockRecorder {
 }
 
 // Close mocks base method.
-func (m *MockEventsWriter) Close() {
+func (m *MockEventsWriter) Close() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Close")
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+
+	return ret0
 }
 
 // Close indicates an expected call of Close.
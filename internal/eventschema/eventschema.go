@@ -0,0 +1,232 @@
+// Package eventschema generates JSON Schema documents of the company mutation events from the Go types
+// of their payloads and finds changes of the schemas which would break consumers of the published events.
+package eventschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	draft = "https://json-schema.org/draft/2020-12/schema"
+
+	// idPrefix turns event names into absolute URIs identifying their schemas, the same way problem types are.
+	idPrefix = "urn:event-type:companies:"
+)
+
+var (
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// Schema is the subset of JSON Schema the events are described with. Objects don't restrict additional
+// properties, since fields are added to the events without changing their schema versions.
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	ID         string             `json:"$id,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Const      json.RawMessage    `json:"const,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+}
+
+// FileName returns the name of the document describing the current schema version of the events.
+func FileName(kind ports.EventKind) string {
+	return fmt.Sprintf("%s.v%d.schema.json", kind.Name, kind.SchemaVersion)
+}
+
+// ID returns the URI identifying the schema of the events with the name and the schema version.
+func ID(name string, schemaVersion int) string {
+	return fmt.Sprintf("%s%s:v%d", idPrefix, name, schemaVersion)
+}
+
+// Generate returns the schema of the events of the kind: the envelope with the payload of the kind.
+func Generate(kind ports.EventKind) (*Schema, error) {
+	data := kind.NewData()
+	if data.EventName() != kind.Name {
+		return nil, fmt.Errorf("generate %s schema: payload belongs to %s events", kind.Name, data.EventName())
+	}
+
+	schema, err := typeSchema(reflect.TypeOf(ports.CompanyMutationEvent{}))
+	if err != nil {
+		return nil, fmt.Errorf("generate %s schema: %w", kind.Name, err)
+	}
+
+	if schema.Properties["data"], err = typeSchema(reflect.TypeOf(data)); err != nil {
+		return nil, fmt.Errorf("generate %s schema: data: %w", kind.Name, err)
+	}
+
+	schema.Schema = draft
+	schema.ID = ID(kind.Name, kind.SchemaVersion)
+	schema.Title = kind.Name
+	schema.Properties["name"].Const, _ = json.Marshal(kind.Name)
+	schema.Properties["schema_version"].Const, _ = json.Marshal(kind.SchemaVersion)
+
+	return schema, nil
+}
+
+// Document returns the schema encoded the way documents are stored.
+func Document(schema *Schema) ([]byte, error) {
+	document, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode schema: %w", err)
+	}
+
+	return append(document, '\n'), nil
+}
+
+// Parse decodes the document produced by Document.
+func Parse(document []byte) (*Schema, error) {
+	schema := new(Schema)
+	if err := json.Unmarshal(document, schema); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// BreakingChanges describes the changes of the published schema which break its consumers: removed properties,
+// properties which aren't always present anymore, changed types, formats and constants. Added properties
+// are ignored by the consumers and aren't reported.
+func BreakingChanges(published, changed *Schema) []string {
+	return breakingChanges("", published, changed)
+}
+
+func breakingChanges(path string, published, changed *Schema) []string {
+	location := path
+	if location == "" {
+		location = "event"
+	}
+
+	var changes []string
+
+	if published.Type != changed.Type {
+		changes = append(changes, fmt.Sprintf("%s: type changed from %q to %q", location, published.Type, changed.Type))
+	}
+
+	if published.Format != changed.Format {
+		changes = append(
+			changes,
+			fmt.Sprintf("%s: format changed from %q to %q", location, published.Format, changed.Format),
+		)
+	}
+
+	if !bytes.Equal(published.Const, changed.Const) {
+		changes = append(changes, fmt.Sprintf("%s: constant changed from %s to %s", location, published.Const, changed.Const))
+	}
+
+	names := make([]string, 0, len(published.Properties))
+	for name := range published.Properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := name
+		if path != "" {
+			propertyPath = path + "." + name
+		}
+
+		property, ok := changed.Properties[name]
+		if !ok {
+			changes = append(changes, propertyPath+": removed")
+			continue
+		}
+
+		if contains(published.Required, name) && !contains(changed.Required, name) {
+			changes = append(changes, propertyPath+": isn't required anymore")
+		}
+
+		changes = append(changes, breakingChanges(propertyPath, published.Properties[name], property)...)
+	}
+
+	return changes
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// typeSchema returns the schema of values of the type encoded as JSON, empty schema is returned for interfaces.
+func typeSchema(t reflect.Type) (*Schema, error) {
+	switch t {
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}, nil
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structSchema returns the schema of the object encoding the struct. Fields which aren't omitted
+// when empty are required, while pointers have to be omitted, since nulls aren't described by the schemas.
+func structSchema(t reflect.Type) (*Schema, error) {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		omitEmpty := contains(strings.Split(options, ","), "omitempty")
+		if field.Type.Kind() == reflect.Pointer && !omitEmpty {
+			return nil, fmt.Errorf("%s: nullable fields aren't supported", name)
+		}
+
+		property, err := typeSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		schema.Properties[name] = property
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema, nil
+}
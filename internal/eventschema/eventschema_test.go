@@ -0,0 +1,162 @@
+package eventschema_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/eventschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// documentsDir holds published documents relatively to the package.
+const documentsDir = "../../api/events"
+
+// TestPublishedSchemas fails when payloads change in a way breaking consumers of the published schema versions,
+// or when the documents of compatible changes haven't been regenerated with "go run ./cmd/eventschema".
+func TestPublishedSchemas(t *testing.T) {
+	for _, kind := range ports.EventKinds() {
+		kind := kind
+
+		t.Run(kind.Name, func(t *testing.T) {
+			schema, err := eventschema.Generate(kind)
+			require.NoError(t, err)
+
+			published, err := os.ReadFile(filepath.Join(documentsDir, eventschema.FileName(kind)))
+			require.NoError(t, err, "schema version %d isn't published, run go run ./cmd/eventschema", kind.SchemaVersion)
+
+			publishedSchema, err := eventschema.Parse(published)
+			require.NoError(t, err)
+			require.Empty(
+				t,
+				eventschema.BreakingChanges(publishedSchema, schema),
+				"payload breaks consumers of schema version %d, increment the version",
+				kind.SchemaVersion,
+			)
+
+			document, err := eventschema.Document(schema)
+			require.NoError(t, err)
+			assert.Equal(t, string(published), string(document), "document is outdated, run go run ./cmd/eventschema")
+		})
+	}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	published := func() *eventschema.Schema {
+		return &eventschema.Schema{
+			Type: "object",
+			Properties: map[string]*eventschema.Schema{
+				"name": {Type: "string", Const: json.RawMessage(`"CompanyUpdated"`)},
+				"data": {
+					Type: "object",
+					Properties: map[string]*eventschema.Schema{
+						"id":   {Type: "string", Format: "uuid"},
+						"name": {Type: "string"},
+					},
+					Required: []string{"id"},
+				},
+			},
+			Required: []string{"name", "data"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(schema *eventschema.Schema)
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			change: func(*eventschema.Schema) {},
+		},
+		{
+			name: "added properties",
+			change: func(schema *eventschema.Schema) {
+				data := schema.Properties["data"]
+				data.Properties["type"] = &eventschema.Schema{Type: "string"}
+				data.Properties["version"] = &eventschema.Schema{Type: "integer"}
+				data.Required = append(data.Required, "version")
+			},
+		},
+		{
+			name: "optional property becomes required",
+			change: func(schema *eventschema.Schema) {
+				schema.Properties["data"].Required = []string{"id", "name"}
+			},
+		},
+		{
+			name: "removed property",
+			change: func(schema *eventschema.Schema) {
+				delete(schema.Properties["data"].Properties, "name")
+			},
+			want: []string{"data.name: removed"},
+		},
+		{
+			name: "required property becomes optional",
+			change: func(schema *eventschema.Schema) {
+				schema.Properties["data"].Required = nil
+			},
+			want: []string{"data.id: isn't required anymore"},
+		},
+		{
+			name: "changed type and format",
+			change: func(schema *eventschema.Schema) {
+				schema.Properties["data"].Properties["id"] = &eventschema.Schema{Type: "integer"}
+			},
+			want: []string{
+				`data.id: type changed from "string" to "integer"`,
+				`data.id: format changed from "uuid" to ""`,
+			},
+		},
+		{
+			name: "renamed event",
+			change: func(schema *eventschema.Schema) {
+				schema.Properties["name"].Const = json.RawMessage(`"CompanyChanged"`)
+			},
+			want: []string{`name: constant changed from "CompanyUpdated" to "CompanyChanged"`},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			changed := published()
+			tt.change(changed)
+
+			assert.Equal(t, tt.want, eventschema.BreakingChanges(published(), changed))
+		})
+	}
+}
+
+// TestGenerate_Payloads checks that events are encoded the way the schemas describe them.
+func TestGenerate_Payloads(t *testing.T) {
+	for _, kind := range ports.EventKinds() {
+		schema, err := eventschema.Generate(kind)
+		require.NoError(t, err)
+
+		payload, err := json.Marshal(&ports.CompanyMutationEvent{Name: kind.Name, Data: kind.NewData()})
+		require.NoError(t, err)
+
+		var event map[string]any
+		require.NoError(t, json.Unmarshal(payload, &event))
+
+		for name := range event {
+			assert.Contains(t, schema.Properties, name, kind.Name)
+		}
+
+		for _, name := range schema.Required {
+			assert.Contains(t, event, name, kind.Name)
+		}
+
+		data, ok := event["data"].(map[string]any)
+		require.True(t, ok, kind.Name)
+
+		for _, name := range schema.Properties["data"].Required {
+			assert.Contains(t, data, name, kind.Name)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// batchRoute is a custom method of the companies collection.
+const batchRoute = "/companies:batch"
+
+type batchRequest struct {
+	Mode       string                  `json:"mode"`
+	DryRun     bool                    `json:"dry_run"`
+	Operations []batchOperationRequest `json:"operations"`
+}
+
+type batchOperationRequest struct {
+	Op      string          `json:"op"`
+	ID      uuid.UUID       `json:"id"`
+	Version int             `json:"version"`
+	Company *domain.Company `json:"company"`
+	Patch   json.RawMessage `json:"patch"`
+}
+
+// batchResult is the outcome of a single operation, Status is the status code
+// the operation would have been responded with if it was requested separately.
+type batchResult struct {
+	Status  int             `json:"status"`
+	Company *domain.Company `json:"company,omitempty"`
+	Error   *problem        `json:"error,omitempty"`
+}
+
+// customMethod rejects requests to any route other than the custom method route. Gin treats the colon
+// of such route as a start of a path parameter, so the route matches any other suffix of the path as well.
+func customMethod(route string) gin.HandlerFunc {
+	method := route[strings.LastIndex(route, ":"):]
+
+	return func(c *gin.Context) {
+		if c.Param(method[1:]) != method {
+			abortWithProblem(c, http.StatusNotFound, codeNotFound, "Route doesn't exist", nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// batchCompanies applies the batch of operations and responds with the result of every operation.
+// Failed operations don't fail the request, unless the whole batch is invalid.
+func (h *HTTPHandler) batchCompanies(c *gin.Context) {
+	request := new(batchRequest)
+	if err := c.ShouldBindJSON(request); err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	if request.Mode == "" {
+		request.Mode = domain.BatchAtomic
+	}
+
+	results := make([]*domain.BatchResult, len(request.Operations))
+	batch := domain.Batch{
+		Mode:       request.Mode,
+		DryRun:     request.DryRun,
+		Operations: make([]domain.BatchOperation, 0, len(request.Operations)),
+	}
+	indexes := make([]int, 0, len(request.Operations))
+
+	var errs domain.ValidationErrors
+
+	for i, op := range request.Operations {
+		if op.Op == domain.OperationDelete && !h.hasScope(c, auth.ScopeDelete) {
+			abortWithProblem(c, http.StatusForbidden, codeForbidden, "Missing required scope "+auth.ScopeDelete, nil)
+			return
+		}
+
+		operation := domain.BatchOperation{Operation: op.Op, ID: op.ID, Version: op.Version, Company: op.Company}
+
+		if op.Op == domain.OperationUpdate {
+			patch, err := decodeBatchPatch(op.Patch)
+			if err != nil {
+				if request.Mode != domain.BatchAtomic {
+					results[i] = &domain.BatchResult{Err: err}
+					continue
+				}
+
+				if _, ok := domain.AsValidationErrors(err); !ok {
+					errorResponse(c, err)
+					return
+				}
+
+				errs = errs.Append(domain.PrefixFields(fmt.Sprintf("operations.%d.", i), err))
+
+				continue
+			}
+
+			operation.Patch = patch
+		}
+
+		batch.Operations = append(batch.Operations, operation)
+		indexes = append(indexes, i)
+	}
+
+	if len(errs) > 0 {
+		errorResponse(c, fmt.Errorf("validation error: %w", errs))
+		return
+	}
+
+	if len(batch.Operations) > 0 || len(request.Operations) == 0 {
+		applied, err := h.companyService.Batch(c, batch)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		for i, result := range applied {
+			results[indexes[i]] = result
+		}
+	}
+
+	response := make([]batchResult, 0, len(results))
+
+	for _, result := range results {
+		if result.Err != nil {
+			status, code, errs := problemFor(result.Err)
+			response = append(response, batchResult{
+				Status: status,
+				Error:  newProblem(c, status, code, result.Err.Error(), errs),
+			})
+
+			continue
+		}
+
+		response = append(response, batchResult{Status: http.StatusOK, Company: result.Company})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": response})
+}
+
+// decodeBatchPatch decodes JSON Merge Patch of the update operation,
+// fields of the validation errors are prefixed with "patch.".
+func decodeBatchPatch(document json.RawMessage) (domain.CompanyPatch, error) {
+	if len(document) == 0 {
+		return domain.CompanyPatch{}, domain.NewValidationError(
+			"patch", domain.RuleRequired, "patch is required by update operation", nil,
+		)
+	}
+
+	patch, err := decodeCompanyPatch(document)
+	if err != nil {
+		return patch, domain.PrefixFields("patch.", err)
+	}
+
+	return patch, nil
+}
+
+// hasScope reports whether the principal stored by AuthCheckMiddleware was granted the scope.
+func (h *HTTPHandler) hasScope(c *gin.Context, scope string) bool {
+	principal, ok := principalFromContext(c)
+
+	return ok && principal.HasScope(scope)
+}
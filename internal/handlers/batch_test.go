@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCompanies(t *testing.T) {
+	t.Parallel()
+
+	service := new(companyServiceStub)
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, service)
+
+	batch := func(path, role, body string) *httptest.ResponseRecorder {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{role}}).
+			SignedString([]byte(signKey))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		return resp
+	}
+
+	resp := batch("/companies:batch", "manager", fmt.Sprintf(`{"mode": "best_effort", "operations": [
+		{"op": "create", "company": {"name": "New company", "type": "Corporations"}},
+		{"op": "create", "company": {"name": "Company", "type": "Corporations"}},
+		{"op": "update", "id": "%[1]s", "patch": {"name": "New name"}},
+		{"op": "update", "id": "%[2]s", "patch": {"name": "New name"}},
+		{"op": "update", "id": "%[1]s", "patch": {"version": 2}},
+		{"op": "delete", "id": "%[1]s", "version": 1}
+	]}`, existingID, uuid.New()))
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var results struct {
+		Results []struct {
+			Status  int
+			Company *struct{ Name string }
+			Error   *struct{ Code string }
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &results))
+	require.Len(t, results.Results, 6)
+
+	statuses := make([]int, 0, len(results.Results))
+	for _, result := range results.Results {
+		statuses = append(statuses, result.Status)
+	}
+
+	assert.Equal(t, []int{200, 409, 200, 404, 422, 200}, statuses)
+	assert.Equal(t, "New company", results.Results[0].Company.Name)
+	assert.Equal(t, "company_name_taken", results.Results[1].Error.Code)
+	assert.Equal(t, "validation_failed", results.Results[4].Error.Code)
+	assert.Len(t, service.batch.Operations, 5)
+
+	// invalid operations fail the whole atomic batch
+	resp = batch("/companies:batch", "manager", fmt.Sprintf(`{"operations": [
+		{"op": "create", "company": {"name": "New company", "type": "Corporations"}},
+		{"op": "update", "id": "%s", "patch": {"random_field": 1}}
+	]}`, existingID))
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"field":"operations.1.patch.random_field"`)
+
+	resp = batch("/companies:batch", "manager", `{"operations": [{"op": "create", "company": {"name": "x", "type": "Corporations"}}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"field":"operations.0.company.name"`)
+
+	operations := strings.TrimSuffix(strings.Repeat(`{"op": "delete", "id": "`+existingID.String()+`"},`, 101), ",")
+	resp = batch("/companies:batch", "manager", `{"operations": [`+operations+`]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"rule":"max_items"`)
+
+	// deletions require the delete scope
+	resp = batch("/companies:batch", "editor", fmt.Sprintf(`{"operations": [{"op": "delete", "id": "%s"}]}`, existingID))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	for _, path := range []string{"/companiesbatch", "/companies:delete"} {
+		assert.Equal(t, http.StatusNotFound, batch(path, "manager", `{}`).Code, path)
+	}
+}
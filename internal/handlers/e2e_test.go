@@ -0,0 +1,725 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/services"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const e2eAppName = "companies-e2e"
+
+// e2eServer is the HTTP handler wired to the company service over in-memory repository and events writer,
+// so requests go through every layer of the app and emitted events are observable.
+type e2eServer struct {
+	t       *testing.T
+	handler *handlers.HTTPHandler
+	relay   *services.OutboxRelay
+	writer  *events.MemoryWriter
+}
+
+func newE2EServer(t *testing.T) *e2eServer {
+	t.Helper()
+
+	logger := zap.NewNop()
+	repo := repositories.NewMemory(logger)
+	writer := events.NewMemoryWriter(0)
+	prometheus := metrics.NewPrometheus()
+
+	return &e2eServer{
+		t: t,
+		handler: handlers.NewTestHTTPHandler(
+			t,
+			handlers.HTTPConfig{
+				IdempotencyStore: repo,
+				Metrics:          prometheus,
+				HealthChecks: []ports.HealthCheck{
+					{Name: "repository", Required: true, Check: repo.Ping},
+					{Name: "events", Check: writer.Ping},
+				},
+			},
+			services.NewCompanyService(e2eAppName, repo, prometheus, logger),
+		),
+		relay:  services.NewOutboxRelay(repo, writer, time.Second, 100, time.Minute, logger),
+		writer: writer,
+	}
+}
+
+// e2eRequest describes the request, empty token means the request is anonymous.
+type e2eRequest struct {
+	method  string
+	path    string
+	token   string
+	body    string
+	headers map[string]string
+}
+
+func (s *e2eServer) do(r e2eRequest) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var body io.Reader = http.NoBody
+	if r.body != "" {
+		body = strings.NewReader(r.body)
+	}
+
+	req := httptest.NewRequest(r.method, r.path, body)
+	if r.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	for name, value := range r.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp := httptest.NewRecorder()
+	s.handler.ServeHTTP(resp, req)
+
+	return resp
+}
+
+// emitted publishes the outbox and returns events published since the previous call.
+func (s *e2eServer) emitted() []*ports.CompanyMutationEvent {
+	s.t.Helper()
+
+	require.NoError(s.t, s.relay.Drain(context.Background()))
+
+	recorded := s.writer.Events()
+	s.writer.Reset()
+
+	published := make([]*ports.CompanyMutationEvent, 0, len(recorded))
+
+	for _, event := range recorded {
+		mutationEvent, ok := event.(*ports.CompanyMutationEvent)
+		require.True(s.t, ok, "unexpected event %T", event)
+
+		published = append(published, mutationEvent)
+	}
+
+	return published
+}
+
+// signToken returns the token with the claims signed by the key, which is known to the verifier if it is signKey.
+func signToken(t *testing.T, key string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	require.NoError(t, err)
+
+	return token
+}
+
+func roleToken(t *testing.T, roles ...string) string {
+	t.Helper()
+
+	return signToken(t, signKey, jwt.MapClaims{"sub": "user", "roles": roles})
+}
+
+func decodeCompany(t *testing.T, resp *httptest.ResponseRecorder) *domain.Company {
+	t.Helper()
+
+	company := new(domain.Company)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), company), resp.Body.String())
+
+	return company
+}
+
+func assertProblem(t *testing.T, resp *httptest.ResponseRecorder, wantCode int, wantProblem string) {
+	t.Helper()
+
+	require.Equal(t, wantCode, resp.Code, resp.Body.String())
+	assert.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+
+	var problem struct {
+		Code   string
+		Status int
+	}
+
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem), resp.Body.String())
+	assert.Equal(t, wantProblem, problem.Code)
+	assert.Equal(t, wantCode, problem.Status)
+}
+
+func assertEvents(t *testing.T, got []*ports.CompanyMutationEvent, companyID uuid.UUID, wantNames ...string) {
+	t.Helper()
+
+	names := make([]string, 0, len(got))
+
+	for _, event := range got {
+		names = append(names, event.Name)
+
+		assert.Equal(t, companyID, event.CompanyID)
+		assert.Equal(t, e2eAppName, event.Producer)
+	}
+
+	assert.Equal(t, wantNames, names)
+}
+
+func TestHTTP_CompanyLifecycle(t *testing.T) {
+	t.Parallel()
+
+	server := newE2EServer(t)
+	editor := roleToken(t, "editor")
+	manager := roleToken(t, "manager")
+
+	resp := server.do(e2eRequest{
+		method: http.MethodPost,
+		path:   "/companies",
+		token:  editor,
+		body:   `{"name": "Company", "description": "About", "employee_cnt": 10, "type": "Corporations"}`,
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, `"1"`, resp.Header().Get("ETag"))
+
+	created := decodeCompany(t, resp)
+	require.NotEqual(t, uuid.Nil, created.ID)
+	assert.Equal(t, 1, created.Version)
+
+	path := "/companies/" + created.ID.String()
+
+	published := server.emitted()
+	assertEvents(t, published, created.ID, "CompanyCreated")
+	assert.Equal(t, ports.NewCompanyCreatedData(created), published[0].Data)
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: path})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, created, decodeCompany(t, resp))
+
+	// the response to PATCH is the company read after the update
+	resp = server.do(e2eRequest{
+		method:  http.MethodPatch,
+		path:    path,
+		token:   editor,
+		body:    `{"name": "New name", "description": null}`,
+		headers: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"1"`},
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, `"2"`, resp.Header().Get("ETag"))
+
+	updated := decodeCompany(t, resp)
+	assert.Equal(t, &domain.Company{
+		ID:          created.ID,
+		Name:        "New name",
+		EmployeeCnt: 10,
+		Type:        "Corporations",
+		Version:     2,
+	}, updated)
+
+	published = server.emitted()
+	assertEvents(t, published, created.ID, "CompanyUpdated")
+	name, description := "New name", ""
+	assert.Equal(
+		t,
+		&ports.CompanyUpdatedData{ID: created.ID, Name: &name, Description: &description},
+		published[0].Data,
+	)
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: path})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, updated, decodeCompany(t, resp))
+	assert.Equal(t, `"2"`, resp.Header().Get("ETag"))
+
+	resp = server.do(e2eRequest{
+		method:  http.MethodPatch,
+		path:    path,
+		token:   editor,
+		body:    `{"employee_cnt": 20}`,
+		headers: map[string]string{"If-Match": `"1"`},
+	})
+	assertProblem(t, resp, http.StatusPreconditionFailed, "version_mismatch")
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: path + "/history", token: roleToken(t, "auditor")})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	history := new(domain.CompanyHistory)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), history))
+	require.Len(t, history.Versions, 2)
+	assert.Equal(t, domain.OperationCreate, history.Versions[0].Operation)
+	assert.Equal(t, domain.OperationUpdate, history.Versions[1].Operation)
+	assert.Equal(t, "user", history.Versions[1].Actor)
+
+	resp = server.do(e2eRequest{
+		method:  http.MethodDelete,
+		path:    path,
+		token:   manager,
+		headers: map[string]string{"If-Match": `"2"`},
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assertEvents(t, server.emitted(), created.ID, "CompanyDeleted")
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: path})
+	assertProblem(t, resp, http.StatusNotFound, "company_not_found")
+
+	resp = server.do(e2eRequest{method: http.MethodDelete, path: path, token: manager})
+	assertProblem(t, resp, http.StatusNotFound, "company_not_found")
+
+	resp = server.do(e2eRequest{method: http.MethodPost, path: path + "/restore", token: manager})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "New name", decodeCompany(t, resp).Name)
+	assertEvents(t, server.emitted(), created.ID, "CompanyRestored")
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: path})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: "/companies?name_prefix=New"})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	page := new(domain.CompanyPage)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), page))
+	require.Len(t, page.Companies, 1)
+	assert.Equal(t, created.ID, page.Companies[0].ID)
+}
+
+func TestHTTP_Errors(t *testing.T) {
+	t.Parallel()
+
+	server := newE2EServer(t)
+
+	resp := server.do(e2eRequest{
+		method: http.MethodPost,
+		path:   "/companies",
+		token:  roleToken(t, "editor"),
+		body:   `{"name": "Company", "employee_cnt": 10, "type": "Corporations"}`,
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	existing := "/companies/" + decodeCompany(t, resp).ID.String()
+	missing := "/companies/" + uuid.NewString()
+
+	server.emitted()
+
+	var (
+		editor  = roleToken(t, "editor")
+		manager = roleToken(t, "manager")
+		auditor = roleToken(t, "auditor")
+		admin   = roleToken(t, "admin")
+		expired = signToken(t, signKey, jwt.MapClaims{
+			"sub":   "user",
+			"roles": []string{"admin"},
+			"exp":   time.Now().Add(-time.Hour).Unix(),
+		})
+		foreign = signToken(t, "other-key", jwt.MapClaims{"sub": "user", "roles": []string{"admin"}})
+	)
+
+	tests := []struct {
+		name        string
+		request     e2eRequest
+		wantCode    int
+		wantProblem string
+	}{
+		{
+			name:        "unknown route",
+			request:     e2eRequest{method: http.MethodGet, path: "/unknown"},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "not_found",
+		},
+		{
+			name:        "get malformed id",
+			request:     e2eRequest{method: http.MethodGet, path: "/companies/not-a-uuid"},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "get missing company",
+			request:     e2eRequest{method: http.MethodGet, path: missing},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "company_not_found",
+		},
+		{
+			name:        "list with unsupported sort",
+			request:     e2eRequest{method: http.MethodGet, path: "/companies?sort=type"},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "list with malformed cursor",
+			request:     e2eRequest{method: http.MethodGet, path: "/companies?cursor=not-a-cursor"},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "export in unsupported format",
+			request:     e2eRequest{method: http.MethodGet, path: "/companies/export?format=xml"},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "create without token",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies", body: `{"name": "New"}`},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name: "create with malformed authorization",
+			request: e2eRequest{
+				method:  http.MethodPost,
+				path:    "/companies",
+				body:    `{"name": "New"}`,
+				headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name:        "create with token signed by another key",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies", token: foreign, body: `{"name": "New"}`},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name:        "create with expired token",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies", token: expired, body: `{"name": "New"}`},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name:        "create without scope",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies", token: auditor, body: `{"name": "New"}`},
+			wantCode:    http.StatusForbidden,
+			wantProblem: "forbidden",
+		},
+		{
+			name:        "create with malformed json",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies", token: editor, body: `{"name": `},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: "invalid_request",
+		},
+		{
+			name: "create invalid company",
+			request: e2eRequest{
+				method: http.MethodPost,
+				path:   "/companies",
+				token:  editor,
+				body:   `{"name": "New", "employee_cnt": 10, "type": "Unknown"}`,
+			},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name: "create with taken name",
+			request: e2eRequest{
+				method: http.MethodPost,
+				path:   "/companies",
+				token:  editor,
+				body:   `{"name": "Company", "employee_cnt": 10, "type": "Corporations"}`,
+			},
+			wantCode:    http.StatusConflict,
+			wantProblem: "company_name_taken",
+		},
+		{
+			name: "create with unsupported content type",
+			request: e2eRequest{
+				method:  http.MethodPost,
+				path:    "/companies",
+				token:   editor,
+				body:    `name=New`,
+				headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			},
+			wantCode:    http.StatusUnsupportedMediaType,
+			wantProblem: "unsupported_media_type",
+		},
+		{
+			name:        "update malformed id",
+			request:     e2eRequest{method: http.MethodPatch, path: "/companies/1", token: editor, body: `{"name": "New"}`},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "update with malformed json",
+			request:     e2eRequest{method: http.MethodPatch, path: existing, token: editor, body: `{"name"`},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: "invalid_request",
+		},
+		{
+			name: "update with malformed If-Match",
+			request: e2eRequest{
+				method:  http.MethodPatch,
+				path:    existing,
+				token:   editor,
+				body:    `{"name": "New"}`,
+				headers: map[string]string{"If-Match": "1"},
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: "invalid_request",
+		},
+		{
+			name: "update with stale version",
+			request: e2eRequest{
+				method:  http.MethodPatch,
+				path:    existing,
+				token:   editor,
+				body:    `{"name": "New"}`,
+				headers: map[string]string{"If-Match": `"5"`},
+			},
+			wantCode:    http.StatusPreconditionFailed,
+			wantProblem: "version_mismatch",
+		},
+		{
+			name:        "update missing company",
+			request:     e2eRequest{method: http.MethodPatch, path: missing, token: editor, body: `{"name": "New"}`},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "company_not_found",
+		},
+		{
+			name:        "update without token",
+			request:     e2eRequest{method: http.MethodPatch, path: existing, body: `{"name": "New"}`},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name:        "delete without scope",
+			request:     e2eRequest{method: http.MethodDelete, path: existing, token: editor},
+			wantCode:    http.StatusForbidden,
+			wantProblem: "forbidden",
+		},
+		{
+			name:        "delete malformed id",
+			request:     e2eRequest{method: http.MethodDelete, path: "/companies/not-a-uuid", token: manager},
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "delete missing company",
+			request:     e2eRequest{method: http.MethodDelete, path: missing, token: manager},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "company_not_found",
+		},
+		{
+			name: "delete with stale version",
+			request: e2eRequest{
+				method:  http.MethodDelete,
+				path:    existing,
+				token:   manager,
+				headers: map[string]string{"If-Match": `"5"`},
+			},
+			wantCode:    http.StatusPreconditionFailed,
+			wantProblem: "version_mismatch",
+		},
+		{
+			name:        "restore without scope",
+			request:     e2eRequest{method: http.MethodPost, path: existing + "/restore", token: editor},
+			wantCode:    http.StatusForbidden,
+			wantProblem: "forbidden",
+		},
+		{
+			name:        "restore missing company",
+			request:     e2eRequest{method: http.MethodPost, path: missing + "/restore", token: manager},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "company_not_found",
+		},
+		{
+			name:        "history without scope",
+			request:     e2eRequest{method: http.MethodGet, path: existing + "/history", token: manager},
+			wantCode:    http.StatusForbidden,
+			wantProblem: "forbidden",
+		},
+		{
+			name:        "history without token",
+			request:     e2eRequest{method: http.MethodGet, path: existing + "/history"},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name:        "history of missing company",
+			request:     e2eRequest{method: http.MethodGet, path: missing + "/history", token: admin},
+			wantCode:    http.StatusNotFound,
+			wantProblem: "company_not_found",
+		},
+		{
+			name:        "batch without token",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies:batch", body: `{"operations": []}`},
+			wantCode:    http.StatusUnauthorized,
+			wantProblem: "unauthorized",
+		},
+		{
+			name: "batch with malformed json",
+			request: e2eRequest{
+				method: http.MethodPost,
+				path:   "/companies:batch",
+				token:  admin,
+				body:   `{"operations": [`,
+			},
+			wantCode:    http.StatusBadRequest,
+			wantProblem: "invalid_request",
+		},
+		{
+			name: "import without scope",
+			request: e2eRequest{
+				method:  http.MethodPost,
+				path:    "/companies/import",
+				token:   auditor,
+				body:    `{"name": "New"}`,
+				headers: map[string]string{"Content-Type": "application/x-ndjson"},
+			},
+			wantCode:    http.StatusForbidden,
+			wantProblem: "forbidden",
+		},
+		{
+			name:        "import with unsupported content type",
+			request:     e2eRequest{method: http.MethodPost, path: "/companies/import", token: admin, body: `[]`},
+			wantCode:    http.StatusUnsupportedMediaType,
+			wantProblem: "unsupported_media_type",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			assertProblem(t, server.do(tt.request), tt.wantCode, tt.wantProblem)
+		})
+	}
+
+	assert.Empty(t, server.emitted(), "failed requests mustn't emit events")
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: existing})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "Company", decodeCompany(t, resp).Name)
+	assert.Equal(t, 1, decodeCompany(t, resp).Version)
+}
+
+func TestHTTP_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	server := newE2EServer(t)
+	request := e2eRequest{
+		method:  http.MethodPost,
+		path:    "/companies",
+		token:   roleToken(t, "editor"),
+		body:    `{"name": "Company", "employee_cnt": 10, "type": "Corporations"}`,
+		headers: map[string]string{"Idempotency-Key": "create-company"},
+	}
+
+	first := server.do(request)
+	require.Equal(t, http.StatusOK, first.Code, first.Body.String())
+
+	replayed := server.do(request)
+	require.Equal(t, http.StatusOK, replayed.Code, replayed.Body.String())
+	assert.Equal(t, first.Body.String(), replayed.Body.String())
+
+	assertEvents(t, server.emitted(), decodeCompany(t, first).ID, "CompanyCreated")
+
+	request.body = `{"name": "Other company", "employee_cnt": 10, "type": "Corporations"}`
+	assertProblem(t, server.do(request), http.StatusUnprocessableEntity, "idempotency_key_reused")
+	assert.Empty(t, server.emitted())
+}
+
+func TestHTTP_BatchImportExport(t *testing.T) {
+	t.Parallel()
+
+	server := newE2EServer(t)
+	admin := roleToken(t, "admin")
+
+	resp := server.do(e2eRequest{
+		method: http.MethodPost,
+		path:   "/companies/import",
+		token:  admin,
+		body: `{"name": "Alpha", "employee_cnt": 1, "type": "NonProfit"}` + "\n" +
+			`{"name": "Beta", "type": "Unknown"}` + "\n",
+		headers: map[string]string{"Content-Type": "application/x-ndjson"},
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var report struct {
+		Total    int
+		Imported int
+		Failed   int
+	}
+
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+
+	imported := server.emitted()
+	require.Len(t, imported, 1)
+	assertEvents(t, imported, imported[0].CompanyID, "CompanyCreated")
+
+	resp = server.do(e2eRequest{
+		method: http.MethodPost,
+		path:   "/companies:batch",
+		token:  admin,
+		body: fmt.Sprintf(`{"mode": "atomic", "operations": [
+			{"op": "create", "company": {"name": "Gamma", "employee_cnt": 3, "type": "Cooperative"}},
+			{"op": "update", "id": "%[1]s", "patch": {"employee_cnt": 2}},
+			{"op": "delete", "id": "%[1]s", "version": 2}
+		]}`, imported[0].CompanyID),
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var batch struct {
+		Results []struct {
+			Status  int
+			Company *domain.Company
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &batch))
+	require.Len(t, batch.Results, 3)
+
+	for _, result := range batch.Results {
+		assert.Less(t, result.Status, http.StatusBadRequest)
+	}
+
+	published := server.emitted()
+	require.Len(t, published, 3)
+	assertEvents(t, published[:1], batch.Results[0].Company.ID, "CompanyCreated")
+	assertEvents(t, published[1:], imported[0].CompanyID, "CompanyUpdated", "CompanyDeleted")
+
+	// the failed operation rolls back the whole atomic batch
+	resp = server.do(e2eRequest{
+		method: http.MethodPost,
+		path:   "/companies:batch",
+		token:  admin,
+		body: fmt.Sprintf(`{"mode": "atomic", "operations": [
+			{"op": "create", "company": {"name": "Delta", "employee_cnt": 4, "type": "Cooperative"}},
+			{"op": "delete", "id": "%s"}
+		]}`, uuid.New()),
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &batch))
+	require.Len(t, batch.Results, 2)
+	assert.Equal(t, http.StatusConflict, batch.Results[0].Status)
+	assert.Equal(t, http.StatusNotFound, batch.Results[1].Status)
+	assert.Empty(t, server.emitted())
+
+	resp = server.do(e2eRequest{method: http.MethodGet, path: "/companies/export?format=ndjson"})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"name":"Gamma"`)
+}
+
+func TestHTTP_ServiceRoutes(t *testing.T) {
+	t.Parallel()
+
+	server := newE2EServer(t)
+
+	for _, path := range []string{"/healthz", "/readyz", "/openapi.json", "/docs", "/metrics"} {
+		resp := server.do(e2eRequest{method: http.MethodGet, path: path})
+		assert.Equal(t, http.StatusOK, resp.Code, path)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes the company version as a strong entity tag.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// parseIfMatch returns the version from If-Match header value produced by setETag.
+// Zero version is returned for "*", which matches any existing company.
+func parseIfMatch(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(value, ",") {
+		return 0, fmt.Errorf("only a single entity tag is supported in If-Match header")
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid entity tag in If-Match header")
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid entity tag in If-Match header")
+	}
+
+	return version, nil
+}
+
+// expectedVersion returns the version the client expects the company to have, zero means any.
+// Aborts the request if the header is invalid or it's missing while being required.
+func (h *HTTPHandler) expectedVersion(c *gin.Context) (int, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		if h.requireIfMatch {
+			abortWithProblem(c, http.StatusPreconditionRequired, codePreconditionRequired, "Missing If-Match header", nil)
+			return 0, false
+		}
+
+		return 0, true
+	}
+
+	version, err := parseIfMatch(ifMatch)
+	if err != nil {
+		errorResponse(c, err)
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	exportRoute = "/companies/export"
+
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// csvColumns are the columns of exported CSV documents.
+var csvColumns = []string{"id", "name", "description", "employee_cnt", "registered", "type", "version"}
+
+// exportFormats maps supported export formats to their content types.
+var exportFormats = map[string]string{
+	"csv":    csvContentType,
+	"ndjson": ndjsonContentType,
+}
+
+type exportCompaniesQuery struct {
+	companyFilterQuery
+	Format string `form:"format"`
+}
+
+// companyEncoder writes companies to the response in one of the export formats.
+type companyEncoder interface {
+	Encode(company *domain.Company) error
+	// Flush writes buffered companies to the response.
+	Flush() error
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvEncoder{writer: writer}, nil
+}
+
+func (e *csvEncoder) Encode(company *domain.Company) error {
+	return e.writer.Write([]string{
+		company.ID.String(),
+		company.Name,
+		company.Description,
+		strconv.Itoa(company.EmployeeCnt),
+		strconv.FormatBool(company.Registered),
+		company.Type,
+		strconv.Itoa(company.Version),
+	})
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{encoder: json.NewEncoder(w)}
+}
+
+func (e *ndjsonEncoder) Encode(company *domain.Company) error {
+	return e.encoder.Encode(company)
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+// exportCompanies streams all companies matching the filter page by page. The response is started
+// with the first page, so errors occurring later can't be reported and truncate the response instead.
+// The write deadline is extended before every page, so the write timeout limits pages, not whole exports.
+func (h *HTTPHandler) exportCompanies(c *gin.Context) {
+	query := new(exportCompaniesQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	if query.Format == "" {
+		query.Format = "csv"
+	}
+
+	contentType, ok := exportFormats[query.Format]
+	if !ok {
+		errorResponse(c, domain.NewValidationError(
+			"format", domain.RuleEnum, "unsupported format \""+query.Format+"\", only csv and ndjson are allowed",
+			[]string{"csv", "ndjson"},
+		))
+
+		return
+	}
+
+	var encoder companyEncoder
+
+	start := func() error {
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", "attachment; filename=companies."+query.Format)
+		c.Status(http.StatusOK)
+
+		if query.Format == "ndjson" {
+			encoder = newNDJSONEncoder(c.Writer)
+			return nil
+		}
+
+		var err error
+		encoder, err = newCSVEncoder(c.Writer)
+
+		return err
+	}
+
+	err := h.companyService.Export(c, query.toFilter(), func(companies []*domain.Company) error {
+		if err := h.extendWriteDeadline(c); err != nil {
+			return err
+		}
+
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		for _, company := range companies {
+			if err := encoder.Encode(company); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+
+		c.Writer.Flush()
+
+		return nil
+	})
+
+	switch {
+	case err != nil && encoder == nil:
+		errorResponse(c, err)
+	case err != nil:
+		logging.FromContext(c.Request.Context(), h.logger).Error("export companies", zap.Error(err))
+		c.Abort()
+	case encoder == nil:
+		// nothing matched the filter, the document is empty
+		if err := start(); err == nil {
+			_ = encoder.Flush()
+		}
+	}
+}
+
+// extendWriteDeadline gives the handler another write timeout to write the response.
+// Writers which don't support deadlines, like the ones of tests, aren't limited by the server anyway.
+func (h *HTTPHandler) extendWriteDeadline(c *gin.Context) error {
+	if h.writeTimeout == 0 {
+		return nil
+	}
+
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(h.writeTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("extend write deadline: %w", err)
+	}
+
+	return nil
+}
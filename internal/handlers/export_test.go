@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCompanies(t *testing.T) {
+	t.Parallel()
+
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, new(companyServiceStub))
+
+	export := func(query string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/companies/export"+query, nil))
+
+		return resp
+	}
+
+	resp := export("")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/csv", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name,description,employee_cnt,registered,type,version\n"+
+		existingID.String()+",Company,,10,false,Corporations,1\n", resp.Body.String())
+
+	resp = export("?format=ndjson")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	require.Len(t, lines, 1)
+
+	var exported domain.Company
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	assert.Equal(t, *company, exported)
+
+	resp = export("?type=NonProfit")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "id,name,description,employee_cnt,registered,type,version\n", resp.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, export("?format=xml").Code)
+}
+
+// slowExportStub exports the company in several pages, each of them taking some time to fetch.
+type slowExportStub struct {
+	companyServiceStub
+	pages int
+	delay time.Duration
+}
+
+func (s *slowExportStub) Export(_ context.Context, _ domain.CompanyFilter, fn func([]*domain.Company) error) error {
+	for i := 0; i < s.pages; i++ {
+		time.Sleep(s.delay)
+
+		if err := fn([]*domain.Company{company}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExportCompanies_WriteTimeout(t *testing.T) {
+	t.Parallel()
+
+	// the export takes longer than the write timeout, but every page is written in time
+	timeouts := handlers.ServerTimeouts{Write: 200 * time.Millisecond}
+	handler := handlers.NewTestHTTPHandler(
+		t, handlers.HTTPConfig{Timeouts: timeouts}, &slowExportStub{pages: 4, delay: 100 * time.Millisecond},
+	)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.WriteTimeout = timeouts.Write
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/companies/export?format=ndjson")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(body)), "\n"), 4)
+}
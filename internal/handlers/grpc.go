@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	companyv1 "github.com/dimaglushkov/epam-xm-test-assignment/api/company/v1"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type GRPCHandler struct {
+	companyv1.UnimplementedCompanyServiceServer
+
+	port           string
+	companyService ports.CompanyService
+	server         *grpc.Server
+	verifier       *auth.Verifier
+	logger         *zap.Logger
+}
+
+func NewGRPCHandler(
+	port string,
+	verifier *auth.Verifier,
+	logger *zap.Logger,
+	companyService ports.CompanyService,
+) *GRPCHandler {
+	handler := new(GRPCHandler)
+	handler.companyService = companyService
+	handler.port = port
+	handler.verifier = verifier
+	handler.logger = logger.Named("grpc")
+
+	handler.server = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		handler.LoggerUnaryInterceptor(),
+		handler.AuthUnaryInterceptor(),
+	))
+	companyv1.RegisterCompanyServiceServer(handler.server, handler)
+
+	return handler
+}
+
+// LoggerUnaryInterceptor propagates "x-request-id" metadata, or generates it if it's missing,
+// stores it in the call context and writes an access log record for every call.
+func (h *GRPCHandler) LoggerUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+
+		var provided string
+		if values := metadata.ValueFromIncomingContext(ctx, "x-request-id"); len(values) > 0 {
+			provided = values[0]
+		}
+
+		id := requestID(provided)
+		ctx = domain.WithRequestID(ctx, id)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", id))
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.String("code", code.String()),
+			zap.Duration("latency", time.Since(start)),
+		}
+
+		logger := logging.FromContext(ctx, h.logger)
+
+		switch code {
+		case codes.OK:
+			logger.Info("call", fields...)
+		case codes.Internal, codes.Unknown:
+			logger.Error("call", fields...)
+		default:
+			logger.Warn("call", fields...)
+		}
+
+		return resp, err
+	}
+}
+
+// AuthUnaryInterceptor verifies the bearer token from "authorization" metadata and checks
+// that the principal was granted the scope required by the method. Public methods are called
+// without authentication and methods missing from the policy are forbidden.
+func (h *GRPCHandler) AuthUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.PermissionDenied, "Access to the method is not configured")
+		}
+
+		if scope == "" {
+			return handler(ctx, req)
+		}
+
+		values := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "Missing authorization metadata")
+		}
+
+		if !strings.HasPrefix(values[0], "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "Invalid authorization metadata format")
+		}
+
+		principal, err := h.verifier.Verify(ctx, strings.TrimPrefix(values[0], "Bearer "))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Invalid token")
+		}
+
+		if !principal.HasScope(scope) {
+			return nil, status.Error(codes.PermissionDenied, "Missing required scope "+scope)
+		}
+
+		return handler(domain.WithActor(ctx, principal.Subject), req)
+	}
+}
+
+func (h *GRPCHandler) Run() error {
+	listener, err := net.Listen("tcp", ":"+h.port)
+	if err != nil {
+		return err
+	}
+
+	return h.Serve(listener)
+}
+
+// Serve accepts connections on the listener until Shutdown is called.
+func (h *GRPCHandler) Serve(listener net.Listener) error {
+	return h.server.Serve(listener)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to complete.
+// Requests which are still running when ctx is done are canceled.
+func (h *GRPCHandler) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		h.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		h.server.Stop()
+
+		return fmt.Errorf("grpc shutdown: %w", ctx.Err())
+	}
+}
+
+func (h *GRPCHandler) GetCompany(ctx context.Context, req *companyv1.GetCompanyRequest) (*companyv1.Company, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	company, err := h.companyService.Get(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return companyToProto(company), nil
+}
+
+func (h *GRPCHandler) ListCompanies(
+	ctx context.Context,
+	req *companyv1.ListCompaniesRequest,
+) (*companyv1.ListCompaniesResponse, error) {
+	query := listCompaniesQuery{
+		companyFilterQuery: companyFilterQuery{
+			Type:           req.Type,
+			Registered:     req.Registered,
+			MinEmployeeCnt: intPtr(req.EmployeeCntMin),
+			MaxEmployeeCnt: intPtr(req.EmployeeCntMax),
+			NamePrefix:     req.GetNamePrefix(),
+		},
+		Sort:   req.GetSort(),
+		Limit:  int(req.GetLimit()),
+		Cursor: req.GetCursor(),
+	}
+
+	params, err := query.toListParams()
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	page, err := h.companyService.List(ctx, params)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	resp := &companyv1.ListCompaniesResponse{
+		Companies:  make([]*companyv1.Company, 0, len(page.Companies)),
+		NextCursor: page.NextCursor,
+	}
+
+	for _, company := range page.Companies {
+		resp.Companies = append(resp.Companies, companyToProto(company))
+	}
+
+	return resp, nil
+}
+
+func (h *GRPCHandler) CreateCompany(
+	ctx context.Context,
+	req *companyv1.CreateCompanyRequest,
+) (*companyv1.Company, error) {
+	company := &domain.Company{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		EmployeeCnt: int(req.GetEmployeeCnt()),
+		Registered:  req.GetRegistered(),
+		Type:        req.GetType(),
+	}
+
+	if err := h.companyService.Create(ctx, company); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return companyToProto(company), nil
+}
+
+func (h *GRPCHandler) UpdateCompany(
+	ctx context.Context,
+	req *companyv1.UpdateCompanyRequest,
+) (*companyv1.Company, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	patch := domain.CompanyPatch{
+		Name:        req.Name,
+		Description: req.Description,
+		EmployeeCnt: intPtr(req.EmployeeCnt),
+		Registered:  req.Registered,
+		Type:        req.Type,
+	}
+
+	if err := h.companyService.Update(ctx, id, int(req.GetVersion()), patch); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	company, err := h.companyService.Get(ctx, id)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return companyToProto(company), nil
+}
+
+func (h *GRPCHandler) DeleteCompany(ctx context.Context, req *companyv1.DeleteCompanyRequest) (*emptypb.Empty, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	if err := h.companyService.Delete(ctx, id, int(req.GetVersion())); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func companyToProto(company *domain.Company) *companyv1.Company {
+	return &companyv1.Company{
+		Id:          company.ID.String(),
+		Name:        company.Name,
+		Description: company.Description,
+		EmployeeCnt: int64(company.EmployeeCnt),
+		Registered:  company.Registered,
+		Type:        company.Type,
+		Version:     int64(company.Version),
+	}
+}
+
+func intPtr(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := int(*v)
+
+	return &i
+}
+
+// grpcError maps service errors to gRPC statuses the same way errorResponse maps them to HTTP codes.
+// Services report failures of canceled and expired requests as internal errors, so the request context
+// is checked as well, and such requests result in Canceled and DeadlineExceeded statuses.
+func grpcError(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	code := codes.InvalidArgument
+	if errors.Is(err, domain.ErrInternalServer) {
+		code = codes.Internal
+	}
+
+	var companyNotFoundErr *domain.CompanyNotFoundError
+	if errors.As(err, &companyNotFoundErr) {
+		code = codes.NotFound
+	}
+
+	var nameAlreadyTakenErr *domain.NameAlreadyTakenError
+	if errors.As(err, &nameAlreadyTakenErr) {
+		code = codes.AlreadyExists
+	}
+
+	var versionMismatchErr *domain.VersionMismatchError
+	if errors.As(err, &versionMismatchErr) {
+		code = codes.FailedPrecondition
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCErrorContext(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	assert.Equal(t, codes.Internal, status.Code(grpcError(context.Background(), domain.ErrInternalServer)))
+	assert.Equal(t, codes.Canceled, status.Code(grpcError(canceled, domain.ErrInternalServer)))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(grpcError(expired, domain.ErrInternalServer)))
+	assert.Equal(t, codes.Canceled, status.Code(grpcError(
+		context.Background(), fmt.Errorf("get company: %w", context.Canceled),
+	)))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(grpcError(
+		context.Background(), fmt.Errorf("get company: %w", context.DeadlineExceeded),
+	)))
+}
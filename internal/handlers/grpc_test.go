@@ -0,0 +1,253 @@
+package handlers_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	companyv1 "github.com/dimaglushkov/epam-xm-test-assignment/api/company/v1"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const signKey = handlers.TestSignKey
+
+var (
+	existingID = uuid.New()
+	company    = &domain.Company{
+		ID:          existingID,
+		Name:        "Company",
+		EmployeeCnt: 10,
+		Type:        "Corporations",
+		Version:     1,
+	}
+)
+
+// companyServiceStub knows a single company and fails most of the other requests with domain errors.
+type companyServiceStub struct {
+	actor     string
+	requestID string
+	created   int
+	patch     *domain.CompanyPatch
+	batch     *domain.Batch
+}
+
+func (s *companyServiceStub) Get(ctx context.Context, id uuid.UUID) (*domain.Company, error) {
+	s.requestID = domain.RequestIDFromContext(ctx)
+
+	if id != existingID {
+		return nil, domain.NewCompanyNotFoundError(id)
+	}
+
+	return company, nil
+}
+
+func (s *companyServiceStub) List(_ context.Context, _ domain.ListParams) (*domain.CompanyPage, error) {
+	return nil, domain.ErrInternalServer
+}
+
+func (s *companyServiceStub) Export(
+	_ context.Context,
+	filter domain.CompanyFilter,
+	fn func(companies []*domain.Company) error,
+) error {
+	if filter.Type != nil && *filter.Type != company.Type {
+		return nil
+	}
+
+	return fn([]*domain.Company{company})
+}
+
+func (s *companyServiceStub) Create(ctx context.Context, c *domain.Company) error {
+	s.actor = domain.ActorFromContext(ctx)
+
+	if c.Name == company.Name {
+		return domain.NewNameAlreadyTakenError(c.Name)
+	}
+
+	s.created++
+	c.SetID()
+	c.Version = 1
+
+	return nil
+}
+
+func (s *companyServiceStub) Update(_ context.Context, id uuid.UUID, version int, patch domain.CompanyPatch) error {
+	if id != existingID {
+		return domain.NewCompanyNotFoundError(id)
+	}
+
+	if version != 0 && version != company.Version {
+		return domain.NewVersionMismatchError(id, version, company.Version)
+	}
+
+	s.patch = &patch
+
+	return nil
+}
+
+func (s *companyServiceStub) Delete(_ context.Context, _ uuid.UUID, _ int) error {
+	return nil
+}
+
+func (s *companyServiceStub) Batch(ctx context.Context, batch domain.Batch) ([]*domain.BatchResult, error) {
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.batch = &batch
+	results := make([]*domain.BatchResult, 0, len(batch.Operations))
+
+	for _, operation := range batch.Operations {
+		switch {
+		case batch.DryRun && operation.Operation == domain.OperationCreate && operation.Company.Name == company.Name:
+			results = append(results, &domain.BatchResult{Err: domain.NewNameAlreadyTakenError(company.Name)})
+		case batch.DryRun:
+			results = append(results, new(domain.BatchResult))
+		case operation.Operation == domain.OperationCreate:
+			err := s.Create(ctx, operation.Company)
+			results = append(results, &domain.BatchResult{Company: operation.Company, Err: err})
+		case operation.ID != existingID:
+			results = append(results, &domain.BatchResult{Err: domain.NewCompanyNotFoundError(operation.ID)})
+		default:
+			results = append(results, &domain.BatchResult{Company: company})
+		}
+	}
+
+	return results, nil
+}
+
+func (s *companyServiceStub) Restore(_ context.Context, id uuid.UUID) (*domain.Company, error) {
+	return nil, domain.NewCompanyNotFoundError(id)
+}
+
+func (s *companyServiceStub) History(_ context.Context, id uuid.UUID) (*domain.CompanyHistory, error) {
+	return nil, domain.NewCompanyNotFoundError(id)
+}
+
+func newGRPCClient(t *testing.T, service *companyServiceStub) companyv1.CompanyServiceClient {
+	t.Helper()
+
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{HMACKey: signKey})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	handler := handlers.NewGRPCHandler("", verifier, zap.NewNop(), service)
+
+	go func() { _ = handler.Serve(listener) }()
+
+	t.Cleanup(func() { _ = handler.Shutdown(context.Background()) })
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return companyv1.NewCompanyServiceClient(conn)
+}
+
+func withToken(t *testing.T, roles ...string) context.Context {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": roles}).
+		SignedString([]byte(signKey))
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCHandlerStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	service := new(companyServiceStub)
+	client := newGRPCClient(t, service)
+
+	resp, err := client.GetCompany(context.Background(), &companyv1.GetCompanyRequest{Id: existingID.String()})
+	require.NoError(t, err)
+	assert.Equal(t, company.Name, resp.GetName())
+	assert.Equal(t, int64(company.Version), resp.GetVersion())
+
+	testCases := []struct {
+		Name string
+		Call func() error
+		Code codes.Code
+	}{
+		{"invalid id", func() error {
+			_, err := client.GetCompany(context.Background(), &companyv1.GetCompanyRequest{Id: "invalid"})
+
+			return err
+		}, codes.InvalidArgument},
+		{"not found", func() error {
+			_, err := client.GetCompany(context.Background(), &companyv1.GetCompanyRequest{Id: uuid.NewString()})
+
+			return err
+		}, codes.NotFound},
+		{"internal", func() error {
+			_, err := client.ListCompanies(context.Background(), &companyv1.ListCompaniesRequest{})
+
+			return err
+		}, codes.Internal},
+		{"invalid sort", func() error {
+			_, err := client.ListCompanies(context.Background(), &companyv1.ListCompaniesRequest{Sort: "type"})
+
+			return err
+		}, codes.InvalidArgument},
+		{"name taken", func() error {
+			_, err := client.CreateCompany(withToken(t, "editor"), &companyv1.CreateCompanyRequest{Name: "Company"})
+
+			return err
+		}, codes.AlreadyExists},
+		{"version mismatch", func() error {
+			_, err := client.UpdateCompany(withToken(t, "editor"), &companyv1.UpdateCompanyRequest{
+				Id:      existingID.String(),
+				Version: 2,
+			})
+
+			return err
+		}, codes.FailedPrecondition},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.Code, status.Code(tc.Call()), tc.Name)
+	}
+
+	assert.Equal(t, "user", service.actor)
+}
+
+func TestGRPCHandlerAuth(t *testing.T) {
+	t.Parallel()
+
+	client := newGRPCClient(t, new(companyServiceStub))
+	req := &companyv1.DeleteCompanyRequest{Id: existingID.String()}
+
+	_, err := client.DeleteCompany(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+	_, err = client.DeleteCompany(ctx, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.DeleteCompany(withToken(t, "editor"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.DeleteCompany(withToken(t, "manager"), req)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout limits the time a single dependency check of the readiness probe may take.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	livenessRoute  = "/healthz"
+	readinessRoute = "/readyz"
+)
+
+const (
+	statusUp   = "up"
+	statusDown = "down"
+)
+
+func isProbe(route string) bool {
+	return route == livenessRoute || route == readinessRoute
+}
+
+type dependencyStatus struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+}
+
+// liveness reports that the process is able to handle requests, it doesn't check any dependency,
+// so the app isn't restarted when one of them is down.
+func (h *HTTPHandler) liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": statusUp})
+}
+
+// readiness checks all dependencies concurrently and responds with 503 if any required one is down.
+func (h *HTTPHandler) readiness(c *gin.Context) {
+	resp := readinessResponse{
+		Status:       statusUp,
+		Dependencies: make(map[string]dependencyStatus, len(h.healthChecks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, check := range h.healthChecks {
+		check := check
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(c, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			status := dependencyStatus{
+				Status:    statusUp,
+				Required:  check.Required,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				status.Status = statusDown
+				status.Error = err.Error()
+
+				if check.Required {
+					resp.Status = statusDown
+				}
+			}
+
+			resp.Dependencies[check.Name] = status
+		}()
+	}
+
+	wg.Wait()
+
+	if resp.Status != statusUp {
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	type dependency struct {
+		Status   string `json:"status"`
+		Required bool   `json:"required"`
+		Error    string `json:"error"`
+	}
+
+	type readiness struct {
+		Status       string                `json:"status"`
+		Dependencies map[string]dependency `json:"dependencies"`
+	}
+
+	tests := []struct {
+		name       string
+		checks     []ports.HealthCheck
+		wantStatus int
+		want       readiness
+	}{
+		{
+			name: "all up",
+			checks: []ports.HealthCheck{
+				{Name: "postgres", Required: true, Check: up},
+				{Name: "kafka", Required: true, Check: up},
+			},
+			wantStatus: http.StatusOK,
+			want: readiness{
+				Status: "up",
+				Dependencies: map[string]dependency{
+					"postgres": {Status: "up", Required: true},
+					"kafka":    {Status: "up", Required: true},
+				},
+			},
+		},
+		{
+			name: "optional down",
+			checks: []ports.HealthCheck{
+				{Name: "postgres", Required: true, Check: up},
+				{Name: "kafka", Required: false, Check: down},
+			},
+			wantStatus: http.StatusOK,
+			want: readiness{
+				Status: "up",
+				Dependencies: map[string]dependency{
+					"postgres": {Status: "up", Required: true},
+					"kafka":    {Status: "down", Error: "connection refused"},
+				},
+			},
+		},
+		{
+			name: "required down",
+			checks: []ports.HealthCheck{
+				{Name: "postgres", Required: true, Check: down},
+				{Name: "kafka", Required: false, Check: up},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want: readiness{
+				Status: "down",
+				Dependencies: map[string]dependency{
+					"postgres": {Status: "down", Required: true, Error: "connection refused"},
+					"kafka":    {Status: "up"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{HealthChecks: tt.checks}, new(companyServiceStub))
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, resp.Code)
+
+			resp = httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantStatus, resp.Code)
+
+			var got readiness
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,45 +1,165 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
 )
 
+// serverName is reported in the spans of handled requests.
+const serverName = "companies"
+
+// defaultIdempotencyTTL is used when HTTPConfig.IdempotencyTTL isn't set.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// ServerTimeouts limit durations of reading requests, writing responses and keeping idle
+// connections open, zero values mean no limit.
+type ServerTimeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
+}
+
+// HTTPConfig describes the HTTP server, zero values disable optional features. Empty Mode keeps the current
+// gin mode, nil IdempotencyStore disables Idempotency-Key header support and nil Metrics disables /metrics
+// endpoint. HealthChecks are performed by /readyz endpoint.
+type HTTPConfig struct {
+	Port             string
+	Mode             string
+	Timeouts         ServerTimeouts
+	RequireIfMatch   bool
+	IdempotencyStore ports.IdempotencyStore
+	IdempotencyTTL   time.Duration
+	Metrics          *metrics.Prometheus
+	HealthChecks     []ports.HealthCheck
+}
+
 type HTTPHandler struct {
-	port           string
 	companyService ports.CompanyService
 	router         *gin.Engine
-	signKey        string
+	server         *http.Server
+	// cancelRequests cancels contexts of the requests which are still running when the shutdown times out
+	cancelRequests context.CancelFunc
+	verifier       *auth.Verifier
+	requireIfMatch bool
+	writeTimeout   time.Duration
+
+	idempotencyStore ports.IdempotencyStore
+	idempotencyTTL   time.Duration
+	metrics          *metrics.Prometheus
+	healthChecks     []ports.HealthCheck
+	logger           *zap.Logger
+
+	spec     *openapi3.T
+	specJSON []byte
 }
 
-func NewHTTPHandler(port, mode, signKey string, companyService ports.CompanyService) *HTTPHandler {
-	if mode != "" {
-		gin.SetMode(mode)
+// NewHTTPHandler creates the handler, requests are authenticated by verifier.
+func NewHTTPHandler(
+	cfg HTTPConfig,
+	verifier *auth.Verifier,
+	logger *zap.Logger,
+	companyService ports.CompanyService,
+) *HTTPHandler {
+	if cfg.Mode != "" {
+		gin.SetMode(cfg.Mode)
+	}
+
+	if cfg.IdempotencyTTL == 0 {
+		cfg.IdempotencyTTL = defaultIdempotencyTTL
 	}
 
 	handler := new(HTTPHandler)
 	handler.companyService = companyService
-	handler.port = port
-	handler.signKey = signKey
+	handler.verifier = verifier
+	handler.requireIfMatch = cfg.RequireIfMatch
+	handler.writeTimeout = cfg.Timeouts.Write
+	handler.idempotencyStore = cfg.IdempotencyStore
+	handler.idempotencyTTL = cfg.IdempotencyTTL
+	handler.metrics = cfg.Metrics
+	handler.healthChecks = cfg.HealthChecks
+	handler.logger = logger.Named("http")
+	handler.spec, handler.specJSON = loadSpec()
 
 	router := gin.New()
+	router.ContextWithFallback = true
 	_ = router.SetTrustedProxies(nil)
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.GET("/companies/:id", handler.getCompany)
-	router.POST("/companies", handler.AuthCheckMiddleware(), handler.createCompany)
-	router.PATCH("/companies/:id", handler.AuthCheckMiddleware(), handler.updateCompany)
-	router.DELETE("/companies/:id", handler.AuthCheckMiddleware(), handler.deleteCompany)
+	router.Use(otelgin.Middleware(serverName))
+	router.Use(handler.RequestIDMiddleware())
+	router.Use(handler.LoggerMiddleware())
+
+	// metrics are observed outside the recovery, so requests which panicked are counted as server errors
+	if cfg.Metrics != nil {
+		router.Use(handler.MetricsMiddleware())
+		router.GET("/metrics", gin.WrapH(cfg.Metrics.Handler()))
+	}
+
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, handler.recoveryHandler))
+
+	router.GET(livenessRoute, handler.liveness)
+	router.GET(readinessRoute, handler.readiness)
+	router.GET(openAPIRoute, handler.openAPI)
+	router.GET(docsRoute, handler.docs)
+	router.GET(docsAssetsRoute+"/*filepath", handler.docsAssets)
+
+	// requests are validated after the idempotency check, so reusing the key for an invalid request
+	// is reported as a key reuse and validation errors are replayed as any other response
+	validate := handler.ValidationMiddleware()
+
+	router.GET("/companies", validate, handler.listCompanies)
+	router.GET("/companies/:id", validate, handler.getCompany)
+	router.GET(exportRoute, validate, handler.exportCompanies)
+
+	protected := router.Group("", handler.AuthCheckMiddleware(), handler.PolicyMiddleware())
+	protected.GET("/companies/:id/history", validate, handler.getCompanyHistory)
+	protected.POST("/companies", handler.IdempotencyMiddleware(), validate, handler.createCompany)
+	protected.PATCH("/companies/:id", handler.IdempotencyMiddleware(), validate, handler.updateCompany)
+	protected.DELETE("/companies/:id", handler.IdempotencyMiddleware(), validate, handler.deleteCompany)
+	protected.POST("/companies/:id/restore", validate, handler.restoreCompany)
+	// uploads are streamed, so they aren't validated against the specification, which requires reading them at once
+	protected.POST(importRoute, handler.importCompanies)
+
+	// the custom method is checked before authentication, so unknown routes it matches aren't reported as protected
+	router.POST(
+		batchRoute,
+		customMethod(batchRoute),
+		handler.AuthCheckMiddleware(),
+		handler.PolicyMiddleware(),
+		handler.IdempotencyMiddleware(),
+		validate,
+		handler.batchCompanies,
+	)
+
+	router.NoRoute(handler.notFound)
+
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
 
 	handler.router = router
+	handler.cancelRequests = cancelRequests
+	handler.server = &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+		ReadHeaderTimeout: cfg.Timeouts.Read,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+	}
 
 	return handler
 }
@@ -48,39 +168,60 @@ func (h *HTTPHandler) AuthCheckMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing Authorization header"})
+			abortWithProblem(c, http.StatusUnauthorized, codeUnauthorized, "Missing Authorization header", nil)
 			return
 		}
 
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
+			abortWithProblem(c, http.StatusUnauthorized, codeUnauthorized, "Invalid Authorization header format", nil)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			return []byte(h.signKey), nil
-		})
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		principal, err := h.verifier.Verify(c, tokenString)
+		if err != nil {
+			abortWithProblem(c, http.StatusUnauthorized, codeUnauthorized, "Invalid token", nil)
 			return
 		}
 
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), principal.Subject))
+
 		c.Next()
 	}
 }
 
+// Run serves requests until Shutdown is called.
 func (h *HTTPHandler) Run() error {
-	return h.router.Run(":" + h.port)
+	err := h.server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to complete until ctx is done.
+// Requests which are still running when ctx is done are canceled and their connections are closed.
+func (h *HTTPHandler) Shutdown(ctx context.Context) error {
+	defer h.cancelRequests()
+
+	if err := h.server.Shutdown(ctx); err != nil {
+		_ = h.server.Close()
+
+		return fmt.Errorf("http shutdown: %w", err)
+	}
+
+	return nil
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
 }
 
 func (h *HTTPHandler) getCompany(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		errorResponse(c, err)
 		return
@@ -92,9 +233,93 @@ func (h *HTTPHandler) getCompany(c *gin.Context) {
 		return
 	}
 
+	setETag(c, company.Version)
 	c.JSON(http.StatusOK, company)
 }
 
+// companyFilterQuery holds query parameters filtering companies.
+type companyFilterQuery struct {
+	Type           *string `form:"type"`
+	Registered     *bool   `form:"registered"`
+	MinEmployeeCnt *int    `form:"employee_cnt_min"`
+	MaxEmployeeCnt *int    `form:"employee_cnt_max"`
+	NamePrefix     string  `form:"name_prefix"`
+}
+
+func (q companyFilterQuery) toFilter() domain.CompanyFilter {
+	return domain.CompanyFilter{
+		Type:           q.Type,
+		Registered:     q.Registered,
+		MinEmployeeCnt: q.MinEmployeeCnt,
+		MaxEmployeeCnt: q.MaxEmployeeCnt,
+		NamePrefix:     q.NamePrefix,
+	}
+}
+
+type listCompaniesQuery struct {
+	companyFilterQuery
+	Sort   string `form:"sort"`
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
+
+func (q listCompaniesQuery) toListParams() (domain.ListParams, error) {
+	params := domain.ListParams{
+		Filter: q.toFilter(),
+		Limit:  q.Limit,
+	}
+
+	if params.Limit == 0 {
+		params.Limit = domain.ListDefaultLimit
+	}
+
+	switch q.Sort {
+	case "", "name":
+	case "-name":
+		params.Descending = true
+	default:
+		return params, domain.NewValidationError(
+			"sort",
+			domain.RuleEnum,
+			fmt.Sprintf("unsupported sort value \"%s\", only \"name\" and \"-name\" are allowed", q.Sort),
+			[]string{"name", "-name"},
+		)
+	}
+
+	if q.Cursor != "" {
+		cursor, err := domain.DecodeListCursor(q.Cursor)
+		if err != nil {
+			return params, err
+		}
+
+		params.After = cursor
+	}
+
+	return params, nil
+}
+
+func (h *HTTPHandler) listCompanies(c *gin.Context) {
+	query := new(listCompaniesQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	params, err := query.toListParams()
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	page, err := h.companyService.List(c, params)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *HTTPHandler) createCompany(c *gin.Context) {
 	company := new(domain.Company)
 	if err := c.ShouldBind(company); err != nil {
@@ -107,23 +332,28 @@ func (h *HTTPHandler) createCompany(c *gin.Context) {
 		return
 	}
 
+	setETag(c, company.Version)
 	c.JSON(http.StatusOK, company)
 }
 
 func (h *HTTPHandler) updateCompany(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		errorResponse(c, err)
 		return
 	}
 
-	fieldsToUpdate := make(map[string]any)
-	if err := c.ShouldBind(&fieldsToUpdate); err != nil {
-		errorResponse(c, err)
+	version, ok := h.expectedVersion(c)
+	if !ok {
 		return
 	}
 
-	if err := h.companyService.Update(c, id, fieldsToUpdate); err != nil {
+	patch, version, ok := h.readPatch(c, id, version)
+	if !ok {
+		return
+	}
+
+	if err := h.companyService.Update(c, id, version, patch); err != nil {
 		errorResponse(c, err)
 		return
 	}
@@ -134,34 +364,67 @@ func (h *HTTPHandler) updateCompany(c *gin.Context) {
 		return
 	}
 
+	setETag(c, company.Version)
 	c.JSON(http.StatusOK, company)
 }
 
+func (h *HTTPHandler) getCompanyHistory(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	history, err := h.companyService.History(c, id)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
 func (h *HTTPHandler) deleteCompany(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+	id, err := parseID(c)
 	if err != nil {
 		errorResponse(c, err)
 		return
 	}
 
-	if err := h.companyService.Delete(c, id); err != nil {
+	version, ok := h.expectedVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.companyService.Delete(c, id, version); err != nil {
 		errorResponse(c, err)
 		return
 	}
 }
 
-func errorResponse(c *gin.Context, err error) {
-	code := http.StatusBadRequest
-	if errors.Is(err, domain.ErrInternalServer) {
-		code = http.StatusInternalServerError
+func (h *HTTPHandler) restoreCompany(c *gin.Context) {
+	id, err := parseID(c)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	company, err := h.companyService.Restore(c, id)
+	if err != nil {
+		errorResponse(c, err)
+		return
 	}
 
-	var companyNotFoundErr *domain.CompanyNotFoundError
-	if errors.As(err, &companyNotFoundErr) {
-		code = http.StatusNotFound
+	setETag(c, company.Version)
+	c.JSON(http.StatusOK, company)
+}
+
+// parseID returns the company id from the route path.
+func parseID(c *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, domain.NewValidationError("id", domain.RuleFormat, "invalid company id: "+err.Error(), "uuid")
 	}
 
-	c.AbortWithStatusJSON(code, gin.H{
-		"error": err.Error(),
-	})
+	return id, nil
 }
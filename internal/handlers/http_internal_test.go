@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestSignKey signs tokens accepted by handlers created by NewTestHTTPHandler.
+const TestSignKey = "test-key"
+
+// NewTestHTTPHandler creates the handler in the gin test mode, it's shared by tests of the package.
+func NewTestHTTPHandler(t *testing.T, cfg HTTPConfig, companyService ports.CompanyService) *HTTPHandler {
+	t.Helper()
+
+	verifier, err := auth.NewVerifier(auth.VerifierConfig{HMACKey: TestSignKey})
+	require.NoError(t, err)
+
+	cfg.Mode = gin.TestMode
+
+	return NewHTTPHandler(cfg, verifier, zap.NewNop(), companyService)
+}
+
+// blockingServiceStub lists companies until the request is canceled.
+type blockingServiceStub struct {
+	ports.CompanyService
+	started  chan struct{}
+	canceled chan struct{}
+}
+
+func (s *blockingServiceStub) List(ctx context.Context, _ domain.ListParams) (*domain.CompanyPage, error) {
+	close(s.started)
+	<-ctx.Done()
+	close(s.canceled)
+
+	return nil, ctx.Err()
+}
+
+func TestHTTPHandlerShutdown(t *testing.T) {
+	t.Parallel()
+
+	service := &blockingServiceStub{started: make(chan struct{}), canceled: make(chan struct{})}
+	handler := NewTestHTTPHandler(t, HTTPConfig{}, service)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = handler.server.Serve(listener) }()
+
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/companies")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	<-service.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Error(t, handler.Shutdown(ctx), "shutdown has to time out while the request is running")
+
+	select {
+	case <-service.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("request still running after the grace period has to be canceled")
+	}
+}
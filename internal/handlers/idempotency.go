@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyKeyMaxLength limits length of the key provided by the client.
+	idempotencyKeyMaxLength = 255
+	// idempotencyLockTimeout is how long the key stays reserved if the response is never stored,
+	// e.g. when the app is stopped while processing the request.
+	idempotencyLockTimeout = time.Minute
+)
+
+// replayedHeaders are the response headers stored together with the response body.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// responseRecorder copies the response body written by the following handlers.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes requests with Idempotency-Key header safe to retry: the response
+// to the first request is stored and replayed for repeated requests with the same key and body,
+// while reusing the key for a different request is rejected. Keys are scoped by the principal
+// stored by AuthCheckMiddleware and stored hashed. Server errors are not stored, so such requests can be retried.
+// Requests without the header are passed through.
+func (h *HTTPHandler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || h.idempotencyStore == nil {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			abortWithProblem(c, http.StatusBadRequest, codeInvalidRequest, "Idempotency-Key header is too long", nil)
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithProblem(c, http.StatusBadRequest, codeInvalidRequest, "Error reading request body", nil)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var subject string
+		if principal, ok := principalFromContext(c); ok {
+			subject = principal.Subject
+		}
+
+		key = idempotencyStoreKey(subject, key)
+
+		fingerprint := requestFingerprint(c, body)
+
+		record, reserved, err := h.idempotencyStore.ReserveIdempotencyKey(c, key, fingerprint, idempotencyLockTimeout)
+		if err != nil {
+			logging.FromContext(c.Request.Context(), h.logger).Error("idempotency", zap.Error(err))
+			abortWithProblem(c, http.StatusInternalServerError, codeInternal, domain.ErrInternalServer.Error(), nil)
+
+			return
+		}
+
+		if !reserved {
+			replayResponse(c, record, fingerprint)
+
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// the response is stored even if the client has already gone, since it's going to retry
+		ctx := context.Background()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := h.idempotencyStore.ReleaseIdempotencyKey(ctx, key); err != nil {
+				logging.FromContext(c.Request.Context(), h.logger).Error("idempotency", zap.Error(err))
+			}
+
+			return
+		}
+
+		header := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+
+		err = h.idempotencyStore.CompleteIdempotencyKey(
+			ctx, key, h.idempotencyTTL, recorder.Status(), header, recorder.body.Bytes(),
+		)
+		if err != nil {
+			logging.FromContext(c.Request.Context(), h.logger).Error("idempotency", zap.Error(err))
+		}
+	}
+}
+
+// replayResponse writes the stored response, unless the key belongs to an unfinished or different request.
+func replayResponse(c *gin.Context, record *ports.IdempotencyRecord, fingerprint string) {
+	switch {
+	case !record.Completed:
+		abortWithProblem(
+			c, http.StatusConflict, codeIdempotencyKeyInUse, "Request with the same Idempotency-Key is being processed", nil,
+		)
+	case record.Fingerprint != fingerprint:
+		abortWithProblem(
+			c, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, "Idempotency-Key was already used with a different request", nil,
+		)
+	default:
+		for name, value := range record.Header {
+			c.Header(name, value)
+		}
+
+		c.Header("Idempotent-Replayed", "true")
+		c.Status(record.StatusCode)
+		_, _ = c.Writer.Write(record.Body)
+		c.Abort()
+	}
+}
+
+// idempotencyStoreKey scopes the key by the subject of the principal. The subject is prefixed by its length,
+// so the pairs can't collide, and the pair is hashed, so stored keys have the same length whatever the subject is.
+func idempotencyStoreKey(subject, key string) string {
+	hash := sha256.New()
+	hash.Write([]byte(strconv.Itoa(len(subject))))
+	hash.Write([]byte{0})
+	hash.Write([]byte(subject))
+	hash.Write([]byte(key))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// requestFingerprint identifies the request by its method, path, precondition, content type and body.
+func requestFingerprint(c *gin.Context, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{
+		c.Request.Method, c.Request.URL.Path, c.GetHeader("If-Match"), c.GetHeader("Content-Type"),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,4 @@
+package handlers
+
+// IdempotencyStoreKey returns the key the response to the request of the subject is stored by.
+var IdempotencyStoreKey = idempotencyStoreKey
@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotencyStoreStub keeps records in memory and never expires them.
+type idempotencyStoreStub struct {
+	mu      sync.Mutex
+	records map[string]*ports.IdempotencyRecord
+}
+
+func (s *idempotencyStoreStub) ReserveIdempotencyKey(
+	_ context.Context,
+	key, fingerprint string,
+	_ time.Duration,
+) (*ports.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+
+	s.records[key] = &ports.IdempotencyRecord{Key: key, Fingerprint: fingerprint}
+
+	return s.records[key], true, nil
+}
+
+func (s *idempotencyStoreStub) CompleteIdempotencyKey(
+	_ context.Context,
+	key string,
+	_ time.Duration,
+	statusCode int,
+	header map[string]string,
+	body []byte,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := s.records[key]
+	record.Completed = true
+	record.StatusCode = statusCode
+	record.Header = header
+	record.Body = body
+
+	return nil
+}
+
+func (s *idempotencyStoreStub) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
+
+func (s *idempotencyStoreStub) DeleteExpiredIdempotencyKeys(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{"editor"}}).
+		SignedString([]byte(signKey))
+	require.NoError(t, err)
+
+	service := new(companyServiceStub)
+	store := &idempotencyStoreStub{records: make(map[string]*ports.IdempotencyRecord)}
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{IdempotencyStore: store}, service)
+
+	send := func(key, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/companies", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		return resp
+	}
+
+	create := func(key, body string) *httptest.ResponseRecorder {
+		return send(key, "application/json", body)
+	}
+
+	body := `{"name": "New company", "employee_cnt": 1, "registered": true, "type": "Corporations"}`
+
+	first := create("key", body)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, 1, service.created)
+
+	replayed := create("key", body)
+	assert.Equal(t, http.StatusOK, replayed.Code)
+	assert.Equal(t, first.Body.String(), replayed.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), replayed.Header().Get("ETag"))
+	assert.Equal(t, "true", replayed.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, service.created)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, create("key", `{"name": "Other company"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, send("key", "application/merge-patch+json", body).Code)
+
+	// client errors are replayed as well
+	taken := `{"name": "Company", "type": "Corporations"}`
+	assert.Equal(t, http.StatusConflict, create("taken", taken).Code)
+	assert.Equal(t, http.StatusConflict, create("taken", taken).Code)
+
+	reserve := func(subject, key string) {
+		_, _, err := store.ReserveIdempotencyKey(
+			context.Background(), handlers.IdempotencyStoreKey(subject, key), "", time.Minute,
+		)
+		require.NoError(t, err)
+	}
+
+	reserve("user", "pending")
+	assert.Equal(t, http.StatusConflict, create("pending", body).Code)
+
+	// keys are scoped by the subject
+	reserve("", "user:other")
+	assert.Equal(t, http.StatusOK, create("other", body).Code)
+
+	assert.Equal(t, http.StatusBadRequest, create(strings.Repeat("k", 256), body).Code)
+
+	assert.Equal(t, http.StatusOK, create("", body).Code)
+	assert.Equal(t, http.StatusOK, create("", body).Code)
+	assert.Equal(t, 4, service.created)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	importRoute = "/companies/import"
+
+	// ndjsonMaxLineSize limits the size of a single company of NDJSON documents.
+	ndjsonMaxLineSize = 64 * 1024
+)
+
+// errInvalidRecord is returned for records which can't be decoded, decoding continues with the next record.
+var errInvalidRecord = errors.New("invalid record")
+
+// companyDecoder reads companies from the uploaded document one by one.
+type companyDecoder interface {
+	// Decode returns the next company and the line of the document it starts at, or io.EOF if there are
+	// no more companies. Records which can't be decoded result in errInvalidRecord or ValidationErrors,
+	// other errors stop decoding.
+	Decode() (*domain.Company, int, error)
+}
+
+// csvDecoder reads CSV documents with a header, which lists columns in any order. Only name and type
+// columns are required, id and version columns are ignored, so exported documents can be imported back.
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	var (
+		columns = make([]string, 0, len(header))
+		seen    = make(map[string]bool, len(header))
+		errs    domain.ValidationErrors
+	)
+
+	for _, column := range header {
+		column = strings.TrimSpace(column)
+
+		switch {
+		case seen[column]:
+			errs = append(errs, domain.NewValidationError(column, domain.RuleUnknown, "duplicate column "+column, nil))
+		case !isCSVColumn(column):
+			errs = append(errs, domain.NewValidationError(column, domain.RuleUnknown, "unknown column "+column, nil))
+		}
+
+		seen[column] = true
+		columns = append(columns, column)
+	}
+
+	for _, column := range []string{"name", "type"} {
+		if !seen[column] {
+			errs = append(errs, domain.NewValidationError(column, domain.RuleRequired, "missing column "+column, nil))
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	return &csvDecoder{reader: reader, columns: columns}, nil
+}
+
+func isCSVColumn(column string) bool {
+	for _, c := range csvColumns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *csvDecoder) Decode() (*domain.Company, int, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, fmt.Errorf("%w: %s", errInvalidRecord, parseErr.Err)
+		}
+
+		return nil, 0, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	company := new(domain.Company)
+
+	var errs domain.ValidationErrors
+
+	for i, column := range d.columns {
+		value := record[i]
+
+		switch column {
+		case "name":
+			company.Name = value
+		case "description":
+			company.Description = value
+		case "type":
+			company.Type = value
+		case "employee_cnt":
+			if value == "" {
+				continue
+			}
+
+			if company.EmployeeCnt, err = strconv.Atoi(value); err != nil {
+				errs = append(errs, domain.NewValidationError(column, domain.RuleType, column+" has to be integer", "integer"))
+			}
+		case "registered":
+			if value == "" {
+				continue
+			}
+
+			if company.Registered, err = strconv.ParseBool(value); err != nil {
+				errs = append(errs, domain.NewValidationError(column, domain.RuleType, column+" has to be boolean", "boolean"))
+			}
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, line, fmt.Errorf("validation error: %w", err)
+	}
+
+	return company, line, nil
+}
+
+// ndjsonDecoder reads documents with a JSON company per line, blank lines are skipped.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), ndjsonMaxLineSize)
+
+	return &ndjsonDecoder{scanner: scanner}
+}
+
+func (d *ndjsonDecoder) Decode() (*domain.Company, int, error) {
+	for d.scanner.Scan() {
+		d.line++
+
+		data := d.scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		company := new(domain.Company)
+		if err := json.Unmarshal(data, company); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) && typeErr.Field != "" {
+				return nil, d.line, domain.NewValidationError(
+					typeErr.Field, domain.RuleType, typeErr.Field+" has to be "+typeErr.Value, typeErr.Value,
+				)
+			}
+
+			return nil, d.line, fmt.Errorf("%w: %s", errInvalidRecord, err)
+		}
+
+		return company, d.line, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, d.line + 1, err
+	}
+
+	return nil, d.line, io.EOF
+}
+
+// importReport describes the outcome of the import. Errors list problems of the failed records
+// by their lines, Imported counts the ones which would be created in case of the dry run.
+type importReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Total    int           `json:"total"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []importError `json:"errors"`
+}
+
+type importError struct {
+	Line  int      `json:"line"`
+	Error *problem `json:"error"`
+}
+
+func (r *importReport) fail(c *gin.Context, line int, err error) {
+	status, code, errs := problemFor(err)
+
+	r.Failed++
+	r.Errors = append(r.Errors, importError{Line: line, Error: newProblem(c, status, code, err.Error(), errs)})
+}
+
+type importCompaniesQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// importCompanies creates companies read from the uploaded document. The document is read as a stream and
+// valid companies are created by best-effort batches, so failed records don't prevent creation of the others
+// and are reported along with their lines. Reading stops at the first record which can't be skipped.
+func (h *HTTPHandler) importCompanies(c *gin.Context) {
+	query := new(importCompaniesQuery)
+	if err := c.ShouldBindQuery(query); err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	var decoder companyDecoder
+
+	switch c.ContentType() {
+	case csvContentType:
+		csvDecoder, err := newCSVDecoder(c.Request.Body)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		decoder = csvDecoder
+	case ndjsonContentType:
+		decoder = newNDJSONDecoder(c.Request.Body)
+	default:
+		abortWithProblem(
+			c,
+			http.StatusUnsupportedMediaType,
+			codeUnsupportedMediaType,
+			fmt.Sprintf("Content-Type has to be one of %s, %s", ndjsonContentType, csvContentType),
+			nil,
+		)
+
+		return
+	}
+
+	report := &importReport{DryRun: query.DryRun, Errors: make([]importError, 0)}
+	batch := domain.Batch{Mode: domain.BatchBestEffort, DryRun: query.DryRun}
+	lines := make([]int, 0, domain.BatchMaxOperations)
+	// every dry run batch is rolled back, so names accepted by the previous batches are tracked here
+	// to report the ones repeated across batches as already taken
+	accepted := make(map[string]struct{})
+
+	// flush creates the batched companies, internal errors fail all of them and stop the import
+	flush := func() bool {
+		if len(batch.Operations) == 0 {
+			return true
+		}
+
+		results, err := h.companyService.Batch(c, batch)
+
+		for i, line := range lines {
+			switch {
+			case err != nil:
+				report.fail(c, line, err)
+			case results[i].Err != nil:
+				report.fail(c, line, results[i].Err)
+			case !query.DryRun:
+				report.Imported++
+			default:
+				name := batch.Operations[i].Company.Name
+				if _, ok := accepted[name]; ok {
+					report.fail(c, line, domain.NewNameAlreadyTakenError(name))
+					continue
+				}
+
+				accepted[name] = struct{}{}
+				report.Imported++
+			}
+		}
+
+		batch.Operations = batch.Operations[:0]
+		lines = lines[:0]
+
+		return err == nil
+	}
+
+	for {
+		company, line, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		report.Total++
+
+		if err == nil {
+			err = company.Validate()
+		}
+
+		if err != nil {
+			report.fail(c, line, err)
+
+			if _, ok := domain.AsValidationErrors(err); ok || errors.Is(err, errInvalidRecord) {
+				continue
+			}
+
+			break
+		}
+
+		batch.Operations = append(batch.Operations, domain.BatchOperation{Operation: domain.OperationCreate, Company: company})
+		lines = append(lines, line)
+
+		if len(batch.Operations) == domain.BatchMaxOperations && !flush() {
+			break
+		}
+	}
+
+	flush()
+
+	c.JSON(http.StatusOK, report)
+}
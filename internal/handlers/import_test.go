@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type importReport struct {
+	DryRun   bool `json:"dry_run"`
+	Total    int
+	Imported int
+	Failed   int
+	Errors   []struct {
+		Line  int
+		Error struct {
+			Code   string
+			Errors []struct{ Field, Rule string }
+		}
+	}
+}
+
+func TestImportCompanies(t *testing.T) {
+	t.Parallel()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{"editor"}}).
+		SignedString([]byte(signKey))
+	require.NoError(t, err)
+
+	service := new(companyServiceStub)
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, service)
+
+	upload := func(query, contentType, body string) (*httptest.ResponseRecorder, importReport) {
+		req := httptest.NewRequest(http.MethodPost, "/companies/import"+query, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", contentType)
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		var report importReport
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		}
+
+		return resp, report
+	}
+
+	lines := func(report importReport) map[int]string {
+		failed := make(map[int]string, len(report.Errors))
+		for _, e := range report.Errors {
+			failed[e.Line] = e.Error.Code
+		}
+
+		return failed
+	}
+
+	csvDocument := strings.Join([]string{
+		"name,type,employee_cnt,registered",
+		"First,Corporations,10,true",
+		"x,Corporations,10,true",
+		"Second,Corporations,many,true",
+		"Company,Corporations,,",
+		`"Multi`,
+		`line",NonProfit,1,false`,
+		"Third,NonProfit",
+	}, "\n")
+
+	resp, report := upload("", "text/csv", csvDocument)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, map[int]string{
+		3: "validation_failed",
+		4: "validation_failed",
+		5: "company_name_taken",
+		8: "invalid_request",
+	}, lines(report))
+	assert.Equal(t, "name", report.Errors[0].Error.Errors[0].Field)
+	assert.Equal(t, "employee_cnt", report.Errors[1].Error.Errors[0].Field)
+
+	resp, report = upload("?dry_run=true", "text/csv", csvDocument)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Imported, "dry run has to report taken names")
+	assert.Equal(t, "company_name_taken", lines(report)[5])
+
+	// records 1 and 101 are checked by different batches
+	records := []string{"name,type,employee_cnt,registered", "Repeated,Corporations,10,true"}
+	for i := 2; i <= 100; i++ {
+		records = append(records, fmt.Sprintf("Company %d,Corporations,10,true", i))
+	}
+
+	records = append(records, "Repeated,Corporations,10,true")
+
+	resp, report = upload("?dry_run=true", "text/csv", strings.Join(records, "\n"))
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 101, report.Total)
+	assert.Equal(t, 100, report.Imported)
+	assert.Equal(t, map[int]string{102: "company_name_taken"}, lines(report))
+
+	ndjsonDocument := strings.Join([]string{
+		`{"name": "First", "type": "Corporations"}`,
+		``,
+		`{"name": "Second", "type": "Corporations", "employee_cnt": "many"}`,
+		`{"name": "Third"`,
+		`{"name": "Fourth", "type": "Ltd"}`,
+	}, "\n")
+
+	resp, report = upload("", "application/x-ndjson", ndjsonDocument)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, map[int]string{
+		3: "validation_failed",
+		4: "invalid_request",
+		5: "validation_failed",
+	}, lines(report))
+
+	resp, _ = upload("", "text/csv", "name,kind\nFirst,Corporations")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"rule":"unknown"`)
+
+	resp, _ = upload("", "application/json", "[]")
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+}
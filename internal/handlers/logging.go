@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern limits request ids accepted from clients, so they are safe to log and pass further.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID returns the id provided by the client if it's valid, otherwise a new one is generated.
+func requestID(provided string) string {
+	if requestIDPattern.MatchString(provided) {
+		return provided
+	}
+
+	return uuid.NewString()
+}
+
+// RequestIDMiddleware propagates X-Request-ID header, or generates it if it's missing,
+// stores it in the request context and returns it in the response.
+func (h *HTTPHandler) RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestID(c.GetHeader(requestIDHeader))
+
+		c.Header(requestIDHeader, id)
+		c.Request = c.Request.WithContext(domain.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+	}
+}
+
+// LoggerMiddleware writes an access log record for every request.
+func (h *HTTPHandler) LoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+		}
+
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			fields = append(fields, zap.String("error", errs.String()))
+		}
+
+		logger := logging.FromContext(c.Request.Context(), h.logger)
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Error("request", fields...)
+		case status >= http.StatusBadRequest:
+			logger.Warn("request", fields...)
+		case isProbe(c.FullPath()):
+			// successful probes are frequent and carry no useful information
+			logger.Debug("request", fields...)
+		default:
+			logger.Info("request", fields...)
+		}
+	}
+}
+
+// recoveryHandler logs recovered panics and responds with internal server error.
+func (h *HTTPHandler) recoveryHandler(c *gin.Context, err any) {
+	logging.FromContext(c.Request.Context(), h.logger).Error("panic recovered", zap.Any("panic", err), zap.Stack("stack"))
+	abortWithProblem(c, http.StatusInternalServerError, codeInternal, domain.ErrInternalServer.Error(), nil)
+}
@@ -0,0 +1,42 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Parallel()
+
+	service := new(companyServiceStub)
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, service)
+
+	get := func(requestID string) string {
+		req := httptest.NewRequest(http.MethodGet, "/companies/"+existingID.String(), nil)
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		return resp.Header().Get("X-Request-ID")
+	}
+
+	assert.Equal(t, "request-1", get("request-1"))
+	assert.Equal(t, "request-1", service.requestID)
+
+	generated := get("")
+	assert.NotEmpty(t, generated)
+	assert.Equal(t, generated, service.requestID)
+
+	replaced := get("invalid request id\n")
+	assert.NotEqual(t, "invalid request id\n", replaced)
+	assert.Equal(t, replaced, service.requestID)
+}
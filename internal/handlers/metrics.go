@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests which didn't match any route, so arbitrary paths don't create new series.
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware observes count and duration of requests by their route pattern.
+func (h *HTTPHandler) MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		h.metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
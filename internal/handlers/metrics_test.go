@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// panickingServiceStub panics on every read of a company.
+type panickingServiceStub struct {
+	companyServiceStub
+}
+
+func (s *panickingServiceStub) Get(_ context.Context, _ uuid.UUID) (*domain.Company, error) {
+	panic("unexpected")
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler := handlers.NewTestHTTPHandler(
+		t, handlers.HTTPConfig{Metrics: metrics.NewPrometheus()}, new(panickingServiceStub),
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return resp
+	}
+
+	require.Equal(t, http.StatusInternalServerError, get("/companies/"+existingID.String()).Code)
+	require.Equal(t, http.StatusNotFound, get("/unknown").Code)
+
+	resp := get("/metrics")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `http_requests_total{method="GET",route="/companies/:id",status="500"} 1`)
+	assert.Contains(t, resp.Body.String(), `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/api/openapi"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	openAPIRoute    = "/openapi.json"
+	docsRoute       = "/docs"
+	docsAssetsRoute = docsRoute + "/swagger-ui"
+)
+
+// validationOptions skip security requirements, they are checked by AuthCheckMiddleware and PolicyMiddleware.
+// All violations are collected, so clients are able to fix every invalid field at once.
+var validationOptions = &openapi3filter.Options{
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	MultiError:         true,
+}
+
+func init() {
+	// merge patches are validated as any other JSON document
+	openapi3filter.RegisterBodyDecoder(mergePatchContentType, func(
+		body io.Reader,
+		_ http.Header,
+		_ *openapi3.SchemaRef,
+		_ openapi3filter.EncodingFn,
+	) (any, error) {
+		var value any
+		if err := json.NewDecoder(body).Decode(&value); err != nil {
+			return nil, &openapi3filter.ParseError{Kind: openapi3filter.KindInvalidFormat, Cause: err}
+		}
+
+		return value, nil
+	})
+}
+
+// openAPIPath converts gin route pattern to the path template of the specification.
+func openAPIPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+func (h *HTTPHandler) openAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.specJSON)
+}
+
+func (h *HTTPHandler) docs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.Docs)
+}
+
+// docsAssets serves the Swagger UI assets embedded into the binary, so the docs don't depend on a CDN.
+func (h *HTTPHandler) docsAssets(c *gin.Context) {
+	c.FileFromFS(c.Param("filepath"), http.FS(openapi.SwaggerUI))
+}
+
+// Routes returns routes registered by the handler.
+func (h *HTTPHandler) Routes() gin.RoutesInfo {
+	return h.router.Routes()
+}
+
+// ValidationMiddleware validates parameters and body of the request against the operation
+// of the specification matching the route. Routes missing from the specification aren't validated.
+func (h *HTTPHandler) ValidationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := openAPIPath(c.FullPath())
+
+		pathItem := h.spec.Paths.Find(path)
+		if pathItem == nil || pathItem.GetOperation(c.Request.Method) == nil {
+			c.Next()
+			return
+		}
+
+		operation := pathItem.GetOperation(c.Request.Method)
+		if body := operation.RequestBody; body != nil && c.Request.ContentLength != 0 &&
+			body.Value.Content.Get(c.GetHeader("Content-Type")) == nil {
+			contentTypes := make([]string, 0, len(body.Value.Content))
+			for contentType := range body.Value.Content {
+				contentTypes = append(contentTypes, contentType)
+			}
+
+			sort.Strings(contentTypes)
+			abortWithProblem(
+				c,
+				http.StatusUnsupportedMediaType,
+				codeUnsupportedMediaType,
+				"Content-Type has to be one of "+strings.Join(contentTypes, ", "),
+				nil,
+			)
+
+			return
+		}
+
+		pathParams := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			pathParams[param.Key] = param.Value
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route: &routers.Route{
+				Spec:      h.spec,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    c.Request.Method,
+				Operation: operation,
+			},
+			Options: validationOptions,
+		}
+
+		if err := openapi3filter.ValidateRequest(c, input); err != nil {
+			validationProblem(c, err)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// schemaRules maps keywords of the specification schemas to the rules reported in validation errors.
+var schemaRules = map[string]string{
+	"required":  domain.RuleRequired,
+	"type":      domain.RuleType,
+	"format":    domain.RuleFormat,
+	"minLength": domain.RuleMinLength,
+	"maxLength": domain.RuleMaxLength,
+	"minimum":   domain.RuleMin,
+	"maximum":   domain.RuleMax,
+	"enum":      domain.RuleEnum,
+	"minItems":  domain.RuleMinItems,
+	"maxItems":  domain.RuleMaxItems,
+	"nullable":  domain.RuleNotNull,
+}
+
+// validationProblem responds with the violations found in the request. Schema violations are reported
+// as validation errors of the parameters or the body fields, others as invalid requests.
+func validationProblem(c *gin.Context, err error) {
+	var errs domain.ValidationErrors
+
+	for _, err := range flattenErrors(err) {
+		var requestErr *openapi3filter.RequestError
+		if !errors.As(err, &requestErr) {
+			abortWithProblem(c, http.StatusBadRequest, codeInvalidRequest, err.Error(), nil)
+			return
+		}
+
+		schemaErrs := schemaErrors(requestErr.Err)
+		if len(schemaErrs) == 0 {
+			reason := requestErr.Reason
+			if reason == "" && requestErr.Err != nil {
+				reason = requestErr.Err.Error()
+			}
+
+			abortWithProblem(c, http.StatusBadRequest, codeInvalidRequest, reason, nil)
+
+			return
+		}
+
+		for _, schemaErr := range schemaErrs {
+			errs = append(errs, schemaValidationError(requestErr, schemaErr))
+		}
+	}
+
+	abortWithProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, errs.Error(), errs)
+}
+
+// flattenErrors returns errors collected in the possibly nested openapi3.MultiError.
+// Wrapped errors aren't unwrapped, so request errors keep their parameters.
+func flattenErrors(err error) []error {
+	multiErr, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, err := range multiErr {
+		errs = append(errs, flattenErrors(err)...)
+	}
+
+	return errs
+}
+
+// schemaErrors returns schema violations described by err, nil is returned if it's not a schema violation.
+func schemaErrors(err error) []*openapi3.SchemaError {
+	if err == nil {
+		return nil
+	}
+
+	var schemaErrs []*openapi3.SchemaError
+
+	for _, err := range flattenErrors(err) {
+		var schemaErr *openapi3.SchemaError
+		if !errors.As(err, &schemaErr) {
+			return nil
+		}
+
+		schemaErrs = append(schemaErrs, schemaErr)
+	}
+
+	return schemaErrs
+}
+
+// schemaValidationError describes the schema violation of the parameter or the body field.
+func schemaValidationError(
+	requestErr *openapi3filter.RequestError,
+	schemaErr *openapi3.SchemaError,
+) *domain.ValidationError {
+	var field string
+	if requestErr.Parameter != nil {
+		field = requestErr.Parameter.Name
+	}
+
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+		if field != "" {
+			field += "."
+		}
+
+		field += strings.Join(pointer, ".")
+	}
+
+	rule, ok := schemaRules[schemaErr.SchemaField]
+	if !ok {
+		rule = schemaErr.SchemaField
+	}
+
+	return domain.NewValidationError(field, rule, schemaErr.Reason, schemaLimit(schemaErr))
+}
+
+// schemaLimit returns the bound or allowed values of the violated schema keyword.
+func schemaLimit(err *openapi3.SchemaError) any {
+	schema := err.Schema
+
+	switch err.SchemaField {
+	case "type":
+		return schema.Type
+	case "format":
+		return schema.Format
+	case "minLength":
+		return schema.MinLength
+	case "maxLength":
+		return schema.MaxLength
+	case "minimum":
+		return schema.Min
+	case "maximum":
+		return schema.Max
+	case "minItems":
+		return schema.MinItems
+	case "maxItems":
+		return schema.MaxItems
+	case "enum":
+		return schema.Enum
+	default:
+		return nil
+	}
+}
+
+// loadSpec returns the specification and its JSON representation. The specification is embedded
+// into the binary and checked by tests, so failing to load it is a programming error.
+func loadSpec() (*openapi3.T, []byte) {
+	spec, err := openapi.Load(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		panic(fmt.Errorf("load openapi: error serializing specification: %w", err))
+	}
+
+	return spec, specJSON
+}
@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// undocumentedRoutes serve the specification itself.
+var undocumentedRoutes = map[string]bool{
+	"GET /openapi.json": true,
+	"GET /docs":         true,
+
+	"GET /docs/swagger-ui/*filepath": true,
+}
+
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func newOpenAPIHandler(t *testing.T) (*handlers.HTTPHandler, openAPISpec) {
+	t.Helper()
+
+	handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, new(companyServiceStub))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var spec openAPISpec
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &spec))
+
+	return handler, spec
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	t.Parallel()
+
+	handler, spec := newOpenAPIHandler(t)
+
+	var documented []string
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	var served []string
+
+	for _, route := range handler.Routes() {
+		if undocumentedRoutes[route.Method+" "+route.Path] {
+			continue
+		}
+
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+
+		served = append(served, route.Method+" "+strings.Join(segments, "/"))
+	}
+
+	sort.Strings(documented)
+	sort.Strings(served)
+	assert.Equal(t, served, documented, "routes and OpenAPI specification drifted apart")
+}
+
+func TestOpenAPI_CompanySchema(t *testing.T) {
+	t.Parallel()
+
+	_, spec := newOpenAPIHandler(t)
+
+	var fields []string
+
+	companyType := reflect.TypeOf(domain.Company{})
+	for i := 0; i < companyType.NumField(); i++ {
+		fields = append(fields, strings.Split(companyType.Field(i).Tag.Get("json"), ",")[0])
+	}
+
+	var properties []string
+	for property := range spec.Components.Schemas["Company"].Properties {
+		properties = append(properties, property)
+	}
+
+	sort.Strings(fields)
+	sort.Strings(properties)
+	assert.Equal(t, fields, properties, "Company schema doesn't match domain.Company")
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	t.Parallel()
+
+	handler, _ := newOpenAPIHandler(t)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{"editor"}}).
+		SignedString([]byte(signKey))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantCode   int
+		wantErrors []*domain.ValidationError
+	}{
+		{
+			name:     "query parameter out of range",
+			method:   http.MethodGet,
+			target:   "/companies?limit=1000",
+			wantCode: http.StatusUnprocessableEntity,
+			wantErrors: []*domain.ValidationError{{
+				Field: "limit", Rule: "max", Message: "number must be at most 100", Limit: float64(100),
+			}},
+		},
+		{
+			name:     "invalid path parameter",
+			method:   http.MethodGet,
+			target:   "/companies/123",
+			wantCode: http.StatusUnprocessableEntity,
+			wantErrors: []*domain.ValidationError{{
+				Field:   "id",
+				Rule:    "format",
+				Message: `string doesn't match the format "uuid" (invalid UUID length: 3)`,
+				Limit:   "uuid",
+			}},
+		},
+		{
+			name:     "invalid body field",
+			method:   http.MethodPost,
+			target:   "/companies",
+			body:     `{"name": "New company", "type": "Unknown"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantErrors: []*domain.ValidationError{{
+				Field:   "type",
+				Rule:    "enum",
+				Message: `value is not one of the allowed values ["Corporations","NonProfit","Cooperative","Sole Proprietorship"]`,
+				Limit:   []any{"Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"},
+			}},
+		},
+		{
+			name:     "missing body field",
+			method:   http.MethodPost,
+			target:   "/companies",
+			body:     `{"type": "NonProfit"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantErrors: []*domain.ValidationError{
+				{Field: "name", Rule: "required", Message: `property "name" is missing`},
+			},
+		},
+		{
+			name:     "several invalid body fields",
+			method:   http.MethodPost,
+			target:   "/companies",
+			body:     `{"name": "Too long company name", "type": "NonProfit", "employee_cnt": -5}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantErrors: []*domain.ValidationError{
+				{Field: "employee_cnt", Rule: "min", Message: "number must be at least 0", Limit: float64(0)},
+				{Field: "name", Rule: "max_length", Message: "maximum string length is 15", Limit: float64(15)},
+			},
+		},
+		{
+			name:     "valid request",
+			method:   http.MethodPost,
+			target:   "/companies",
+			body:     `{"name": "New company", "type": "NonProfit", "employee_cnt": 10}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, tt.wantCode, resp.Code)
+
+			if tt.wantErrors != nil {
+				var problem struct {
+					Code   string
+					Errors []*domain.ValidationError
+				}
+
+				assert.Equal(t, "application/problem+json", resp.Header().Get("Content-Type"))
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, "validation_failed", problem.Code)
+				assert.ElementsMatch(t, tt.wantErrors, problem.Errors)
+			}
+		})
+	}
+}
+
+func TestOpenAPI_Docs(t *testing.T) {
+	t.Parallel()
+
+	handler, _ := newOpenAPIHandler(t)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return resp
+	}
+
+	resp := get("/docs")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "https://", "docs mustn't depend on a CDN")
+	assert.Contains(t, resp.Body.String(), `src="/docs/swagger-ui/swagger-ui-bundle.js"`)
+
+	for _, asset := range []string{"/docs/swagger-ui/swagger-ui-bundle.js", "/docs/swagger-ui/swagger-ui.css"} {
+		resp = get(asset)
+		require.Equal(t, http.StatusOK, resp.Code, asset)
+		assert.NotEmpty(t, resp.Body.Bytes(), asset)
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("/docs/swagger-ui/unknown.js").Code)
+}
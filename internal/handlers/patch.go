@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// errPatchConflict is returned when JSON Patch can't be applied to the current company.
+var errPatchConflict = errors.New("patch can't be applied")
+
+// readPatch reads the company patch from the request body, which is either RFC 7396 JSON Merge Patch
+// (plain JSON objects are treated as such) or RFC 6902 JSON Patch document. JSON Patch is applied
+// to the current company, so the returned version is the version of the patched company.
+// Aborts the request if the patch can't be read.
+func (h *HTTPHandler) readPatch(c *gin.Context, id uuid.UUID, version int) (domain.CompanyPatch, int, bool) {
+	var patch domain.CompanyPatch
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		abortWithProblem(c, http.StatusBadRequest, codeInvalidRequest, "Error reading request body", nil)
+		return patch, 0, false
+	}
+
+	switch c.ContentType() {
+	case gin.MIMEJSON, mergePatchContentType:
+	case jsonPatchContentType:
+		company, err := h.companyService.Get(c, id)
+		if err != nil {
+			errorResponse(c, err)
+			return patch, 0, false
+		}
+
+		if version != 0 && version != company.Version {
+			errorResponse(c, domain.NewVersionMismatchError(id, version, company.Version))
+			return patch, 0, false
+		}
+
+		version = company.Version
+
+		if body, err = jsonPatchToMergePatch(company, body); err != nil {
+			errorResponse(c, err)
+			return patch, 0, false
+		}
+	default:
+		abortWithProblem(
+			c,
+			http.StatusUnsupportedMediaType,
+			codeUnsupportedMediaType,
+			fmt.Sprintf("Content-Type has to be one of %s, %s, %s", gin.MIMEJSON, jsonPatchContentType, mergePatchContentType),
+			nil,
+		)
+
+		return patch, 0, false
+	}
+
+	patch, err = decodeCompanyPatch(body)
+	if err != nil {
+		errorResponse(c, err)
+		return patch, 0, false
+	}
+
+	return patch, version, true
+}
+
+// jsonPatchToMergePatch applies JSON Patch document to the company
+// and returns JSON Merge Patch document describing the changes.
+func jsonPatchToMergePatch(company *domain.Company, document []byte) ([]byte, error) {
+	patch, err := jsonpatch.DecodePatch(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Patch document: %w", err)
+	}
+
+	original, err := json.Marshal(company)
+	if err != nil {
+		return nil, domain.ErrInternalServer
+	}
+
+	modified, err := patch.Apply(original)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errPatchConflict, err)
+	}
+
+	mergePatch, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errPatchConflict, err)
+	}
+
+	return mergePatch, nil
+}
+
+// decodeCompanyPatch decodes JSON Merge Patch document of the company. Null values remove fields,
+// which is allowed for the description only, it's cleared. Unknown and read-only fields are rejected.
+func decodeCompanyPatch(document []byte) (domain.CompanyPatch, error) {
+	var (
+		patch  domain.CompanyPatch
+		fields map[string]json.RawMessage
+		errs   domain.ValidationErrors
+	)
+
+	if err := json.Unmarshal(document, &fields); err != nil {
+		return patch, fmt.Errorf("invalid JSON Merge Patch document: %w", err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := fields[name]
+
+		switch name {
+		case "name":
+			errs = errs.Append(decodeField(name, value, "string", false, &patch.Name))
+		case "description":
+			errs = errs.Append(decodeField(name, value, "string", true, &patch.Description))
+		case "employee_cnt":
+			errs = errs.Append(decodeField(name, value, "integer", false, &patch.EmployeeCnt))
+		case "registered":
+			errs = errs.Append(decodeField(name, value, "boolean", false, &patch.Registered))
+		case "type":
+			errs = errs.Append(decodeField(name, value, "string", false, &patch.Type))
+		case "id", "version":
+			errs = append(errs, domain.NewValidationError(name, domain.RuleReadOnly, name+" can't be changed", nil))
+		default:
+			errs = append(errs, domain.NewValidationError(name, domain.RuleUnknown, "unknown field "+name, nil))
+		}
+	}
+
+	if err := errs.Err(); err != nil {
+		return patch, fmt.Errorf("validation error: %w", err)
+	}
+
+	return patch, nil
+}
+
+// decodeField decodes the value of the field into target, null is decoded into zero value if the field is nullable.
+func decodeField[T any](name string, value json.RawMessage, expectedType string, nullable bool, target **T) error {
+	if string(value) == "null" {
+		if !nullable {
+			return domain.NewValidationError(name, domain.RuleNotNull, name+" can't be null", nil)
+		}
+
+		*target = new(T)
+
+		return nil
+	}
+
+	decoded := new(T)
+	if err := json.Unmarshal(value, decoded); err != nil {
+		return domain.NewValidationError(name, domain.RuleType, name+" has to be "+expectedType, expectedType)
+	}
+
+	*target = decoded
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/handlers"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateCompany(t *testing.T) {
+	t.Parallel()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{"editor"}}).
+		SignedString([]byte(signKey))
+	require.NoError(t, err)
+
+	var (
+		name        = "New name"
+		empty       = ""
+		employeeCnt = 20
+	)
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		wantCode    int
+		wantPatch   *domain.CompanyPatch
+		wantProblem string
+		wantErrors  []*domain.ValidationError
+	}{
+		{
+			name:        "merge patch",
+			contentType: "application/merge-patch+json",
+			body:        `{"name": "New name", "description": null}`,
+			wantCode:    http.StatusOK,
+			wantPatch:   &domain.CompanyPatch{Name: &name, Description: &empty},
+		},
+		{
+			name:        "plain json",
+			contentType: "application/json",
+			body:        `{"employee_cnt": 20}`,
+			wantCode:    http.StatusOK,
+			wantPatch:   &domain.CompanyPatch{EmployeeCnt: &employeeCnt},
+		},
+		{
+			name:        "unknown and read-only fields",
+			contentType: "application/json",
+			body:        `{"version": 3, "id": "bd2ae8e4-4d6b-4bb2-8f5a-17f1b6ad5b3e", "random_field": 1}`,
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+			wantErrors: []*domain.ValidationError{
+				{Field: "id", Rule: domain.RuleReadOnly, Message: "id can't be changed"},
+				{Field: "random_field", Rule: domain.RuleUnknown, Message: "unknown field random_field"},
+				{Field: "version", Rule: domain.RuleReadOnly, Message: "version can't be changed"},
+			},
+		},
+		{
+			name:        "null name",
+			contentType: "application/merge-patch+json",
+			body:        `{"name": null}`,
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "fractional employee count",
+			contentType: "application/json",
+			body:        `{"employee_cnt": 1.5}`,
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+		},
+		{
+			name:        "json patch",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/name", "value": "Company"}, {"op": "replace", "path": "/name", "value": "New name"}]`,
+			wantCode:    http.StatusOK,
+			wantPatch:   &domain.CompanyPatch{Name: &name},
+		},
+		{
+			name:        "json patch of read-only field",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "replace", "path": "/version", "value": 5}]`,
+			wantCode:    http.StatusUnprocessableEntity,
+			wantProblem: "validation_failed",
+			wantErrors: []*domain.ValidationError{
+				{Field: "version", Rule: domain.RuleReadOnly, Message: "version can't be changed"},
+			},
+		},
+		{
+			name:        "failed json patch test",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/name", "value": "Other name"}]`,
+			wantCode:    http.StatusConflict,
+			wantProblem: "patch_conflict",
+		},
+		{
+			name:        "json patch of outdated version",
+			contentType: "application/json-patch+json",
+			ifMatch:     `"5"`,
+			body:        `[{"op": "replace", "path": "/name", "value": "New name"}]`,
+			wantCode:    http.StatusPreconditionFailed,
+			wantProblem: "version_mismatch",
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `name=New name`,
+			wantCode:    http.StatusUnsupportedMediaType,
+			wantProblem: "unsupported_media_type",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := new(companyServiceStub)
+			handler := handlers.NewTestHTTPHandler(t, handlers.HTTPConfig{}, service)
+
+			req := httptest.NewRequest(http.MethodPatch, "/companies/"+existingID.String(), strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tt.wantCode, resp.Code, resp.Body.String())
+			assert.Equal(t, tt.wantPatch, service.patch)
+
+			if tt.wantProblem != "" {
+				var problem struct {
+					Code   string
+					Errors []*domain.ValidationError
+				}
+
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.wantProblem, problem.Code)
+
+				if tt.wantErrors != nil {
+					assert.Equal(t, tt.wantErrors, problem.Errors)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	companyv1 "github.com/dimaglushkov/epam-xm-test-assignment/api/company/v1"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// routeScopes maps protected routes to the scope required to access them.
+var routeScopes = map[string]string{
+	"POST /companies":             auth.ScopeWrite,
+	"POST " + batchRoute:          auth.ScopeWrite,
+	"POST " + importRoute:         auth.ScopeWrite,
+	"PATCH /companies/:id":        auth.ScopeWrite,
+	"DELETE /companies/:id":       auth.ScopeDelete,
+	"POST /companies/:id/restore": auth.ScopeDelete,
+	"GET /companies/:id/history":  auth.ScopeAudit,
+}
+
+// methodScopes maps gRPC methods to the scope required to call them, empty scope marks public methods.
+var methodScopes = map[string]string{
+	companyv1.CompanyService_GetCompany_FullMethodName:    "",
+	companyv1.CompanyService_ListCompanies_FullMethodName: "",
+	companyv1.CompanyService_CreateCompany_FullMethodName: auth.ScopeWrite,
+	companyv1.CompanyService_UpdateCompany_FullMethodName: auth.ScopeWrite,
+	companyv1.CompanyService_DeleteCompany_FullMethodName: auth.ScopeDelete,
+}
+
+// PolicyMiddleware checks that the principal stored by AuthCheckMiddleware was granted
+// the scope required by the route. Routes missing from the policy are forbidden.
+func (h *HTTPHandler) PolicyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope, ok := routeScopes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			abortWithProblem(c, http.StatusForbidden, codeForbidden, "Access to the route is not configured", nil)
+			return
+		}
+
+		principal, ok := principalFromContext(c)
+		if !ok || !principal.HasScope(scope) {
+			abortWithProblem(c, http.StatusForbidden, codeForbidden, "Missing required scope "+scope, nil)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func principalFromContext(c *gin.Context) (*auth.Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+
+	principal, ok := value.(*auth.Principal)
+
+	return principal, ok
+}
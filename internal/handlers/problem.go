@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix turns problem codes into absolute URIs identifying problem types.
+const problemTypePrefix = "urn:problem-type:companies:"
+
+// Problem codes are stable, so clients are able to handle errors without parsing their details.
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeValidationFailed     = "validation_failed"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeCompanyNotFound      = "company_not_found"
+	codeCompanyNameTaken     = "company_name_taken"
+	codeVersionMismatch      = "version_mismatch"
+	codePreconditionRequired = "precondition_required"
+	codeIdempotencyKeyInUse  = "idempotency_key_in_use"
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+	codePatchConflict        = "patch_conflict"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeBatchAborted         = "batch_aborted"
+	codeInternal             = "internal_error"
+)
+
+// problem is RFC 7807 problem details object extended with the problem code, id of the request
+// and violations of the validation rules, if the request is invalid.
+type problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Errors    domain.ValidationErrors `json:"errors,omitempty"`
+}
+
+// newProblem returns the problem details of the request handled by c.
+func newProblem(c *gin.Context, status int, code, detail string, errs domain.ValidationErrors) *problem {
+	return &problem{
+		Type:      problemTypePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: domain.RequestIDFromContext(c.Request.Context()),
+		Errors:    errs,
+	}
+}
+
+// abortWithProblem responds with application/problem+json body and stops the request handling.
+func abortWithProblem(c *gin.Context, status int, code, detail string, errs domain.ValidationErrors) {
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, newProblem(c, status, code, detail, errs))
+}
+
+// errorResponse responds with the problem corresponding to the error returned by the service.
+func errorResponse(c *gin.Context, err error) {
+	status, code, errs := problemFor(err)
+	abortWithProblem(c, status, code, err.Error(), errs)
+}
+
+// problemFor returns status and code of the problem corresponding to the error returned by the service,
+// together with the violated validation rules, if there are any.
+func problemFor(err error) (int, string, domain.ValidationErrors) {
+	var (
+		companyNotFoundErr  *domain.CompanyNotFoundError
+		nameAlreadyTakenErr *domain.NameAlreadyTakenError
+		versionMismatchErr  *domain.VersionMismatchError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrInternalServer):
+		return http.StatusInternalServerError, codeInternal, nil
+	case errors.As(err, &companyNotFoundErr):
+		return http.StatusNotFound, codeCompanyNotFound, nil
+	case errors.As(err, &nameAlreadyTakenErr):
+		return http.StatusConflict, codeCompanyNameTaken, nil
+	case errors.As(err, &versionMismatchErr):
+		return http.StatusPreconditionFailed, codeVersionMismatch, nil
+	case errors.Is(err, errPatchConflict):
+		return http.StatusConflict, codePatchConflict, nil
+	case errors.Is(err, domain.ErrBatchAborted):
+		return http.StatusConflict, codeBatchAborted, nil
+	default:
+		if errs, ok := domain.AsValidationErrors(err); ok {
+			return http.StatusUnprocessableEntity, codeValidationFailed, errs
+		}
+
+		return http.StatusBadRequest, codeInvalidRequest, nil
+	}
+}
+
+func (h *HTTPHandler) notFound(c *gin.Context) {
+	abortWithProblem(c, http.StatusNotFound, codeNotFound, "Route doesn't exist", nil)
+}
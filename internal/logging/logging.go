@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Supported log formats.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// New returns a logger writing records of the given level and above to stderr.
+// Level is one of "debug", "info", "warn" and "error".
+func New(level, format string) (*zap.Logger, error) {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, fmt.Errorf("new logger: %w", err)
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	switch format {
+	case FormatJSON:
+	case FormatConsole:
+		cfg.Encoding = FormatConsole
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	default:
+		return nil, fmt.Errorf("new logger: unsupported format \"%s\"", format)
+	}
+
+	return cfg.Build()
+}
+
+// FromContext returns the logger annotated with the request id, actor and trace id stored in ctx.
+func FromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := make([]zap.Field, 0, 3)
+
+	if requestID := domain.RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+
+	if actor := domain.ActorFromContext(ctx); actor != "" {
+		fields = append(fields, zap.String("actor", actor))
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return logger.With(fields...)
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+)
+
+// EventsWriter observes counts, errors and durations of writes to the wrapped writer.
+type EventsWriter struct {
+	writer  ports.EventsWriter
+	metrics *Prometheus
+}
+
+var _ ports.EventsWriter = (*EventsWriter)(nil)
+
+func NewEventsWriter(ew ports.EventsWriter, metrics *Prometheus) *EventsWriter {
+	return &EventsWriter{
+		writer:  ew,
+		metrics: metrics,
+	}
+}
+
+func (w *EventsWriter) Write(ctx context.Context, data ...any) error {
+	start := time.Now()
+	err := w.writer.Write(ctx, data...)
+	w.metrics.ObserveEventWrite(len(data), time.Since(start), err)
+
+	return err
+}
+
+func (w *EventsWriter) Close() error {
+	return w.writer.Close()
+}
@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./internal/core/ports/metrics.go
+
+// Package metrics is a generated GoMock package.
+package metrics
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// CompanyMutated mocks base method.
+func (m *MockMetrics) CompanyMutated(operation string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CompanyMutated", operation)
+}
+
+// CompanyMutated indicates an expected call of CompanyMutated.
+func (mr *MockMetricsMockRecorder) CompanyMutated(operation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompanyMutated", reflect.TypeOf((*MockMetrics)(nil).CompanyMutated), operation)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exposes pgxpool statistics, which are read on every scrape.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{
+		pool: pool,
+
+		acquiredConns: prometheus.NewDesc("db_pool_acquired_conns",
+			"Number of currently acquired connections.", nil, nil),
+		idleConns: prometheus.NewDesc("db_pool_idle_conns",
+			"Number of currently idle connections.", nil, nil),
+		totalConns: prometheus.NewDesc("db_pool_total_conns",
+			"Total number of connections in the pool.", nil, nil),
+		maxConns: prometheus.NewDesc("db_pool_max_conns",
+			"Maximum size of the pool.", nil, nil),
+		acquireCount: prometheus.NewDesc("db_pool_acquires_total",
+			"Number of successful connection acquires.", nil, nil),
+		canceledAcquireCount: prometheus.NewDesc("db_pool_canceled_acquires_total",
+			"Number of connection acquires canceled by context.", nil, nil),
+		emptyAcquireCount: prometheus.NewDesc("db_pool_empty_acquires_total",
+			"Number of connection acquires which had to wait for a connection.", nil, nil),
+		acquireDuration: prometheus.NewDesc("db_pool_acquire_duration_seconds_total",
+			"Total duration of successful connection acquires.", nil, nil),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.canceledAcquireCount
+	ch <- c.emptyAcquireCount
+	ch <- c.acquireDuration
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(
+		c.canceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()),
+	)
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
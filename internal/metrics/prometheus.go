@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus holds app metrics and exposes them in Prometheus text format.
+// Besides implementing ports.Metrics, it's used by instrumented adapters to observe
+// HTTP requests, repository queries and event writes.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	dbQueryDuration    *prometheus.HistogramVec
+	eventWrites        *prometheus.CounterVec
+	eventWriteMessages prometheus.Counter
+	eventWriteDuration prometheus.Histogram
+	companyMutations   *prometheus.CounterVec
+}
+
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of handled HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of handled HTTP requests by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of repository calls by method and status (ok, error or the domain error).",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "status"}),
+		eventWrites: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_writes_total",
+			Help: "Number of event batch writes by status (ok or error).",
+		}, []string{"status"}),
+		eventWriteMessages: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kafka_written_messages_total",
+			Help: "Number of successfully written events.",
+		}),
+		eventWriteDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "kafka_write_duration_seconds",
+			Help:    "Duration of event batch writes.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		companyMutations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "companies_mutations_total",
+			Help: "Number of committed company mutations by operation.",
+		}, []string{"operation"}),
+	}
+
+	p.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		p.httpRequests,
+		p.httpDuration,
+		p.dbQueryDuration,
+		p.eventWrites,
+		p.eventWriteMessages,
+		p.eventWriteDuration,
+		p.companyMutations,
+	)
+
+	return p
+}
+
+// Register adds extra collectors, such as PoolCollector, to the exposed metrics.
+func (p *Prometheus) Register(collector prometheus.Collector) error {
+	return p.registry.Register(collector)
+}
+
+// Handler serves the metrics in Prometheus text format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func (p *Prometheus) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	p.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	p.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (p *Prometheus) ObserveDBQuery(method string, duration time.Duration, err error) {
+	p.dbQueryDuration.WithLabelValues(method, dbQueryStatus(err)).Observe(duration.Seconds())
+}
+
+func (p *Prometheus) ObserveEventWrite(messages int, duration time.Duration, err error) {
+	p.eventWrites.WithLabelValues(status(err)).Inc()
+	p.eventWriteDuration.Observe(duration.Seconds())
+
+	if err == nil {
+		p.eventWriteMessages.Add(float64(messages))
+	}
+}
+
+func (p *Prometheus) CompanyMutated(operation string) {
+	p.companyMutations.WithLabelValues(operation).Inc()
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
+
+// dbQueryStatus tells database failures from calls rejected by the domain rules, such as a missing company
+// or a taken name, which are reported to the clients and don't mean anything is wrong with the database.
+func dbQueryStatus(err error) string {
+	var (
+		companyNotFoundErr  *domain.CompanyNotFoundError
+		nameAlreadyTakenErr *domain.NameAlreadyTakenError
+		versionMismatchErr  *domain.VersionMismatchError
+	)
+
+	switch {
+	case errors.As(err, &companyNotFoundErr):
+		return "not_found"
+	case errors.As(err, &nameAlreadyTakenErr):
+		return "name_taken"
+	case errors.As(err, &versionMismatchErr):
+		return "version_mismatch"
+	default:
+		return status(err)
+	}
+}
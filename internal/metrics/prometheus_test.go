@@ -0,0 +1,64 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/events"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/metrics"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, prometheus *metrics.Prometheus) string {
+	t.Helper()
+
+	resp := httptest.NewRecorder()
+	prometheus.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestPrometheus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := uuid.New()
+
+	mockRepo := repositories.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetCompanyByID(gomock.Any(), id).Return(nil, errors.New("connection refused"))
+	mockRepo.EXPECT().GetCompanyByID(gomock.Any(), id).Return(nil, domain.NewCompanyNotFoundError(id))
+
+	mockWriter := events.NewMockEventsWriter(ctrl)
+	mockWriter.EXPECT().Write(gomock.Any(), 1, 2).Return(nil)
+
+	prometheus := metrics.NewPrometheus()
+
+	repo := metrics.NewRepository(mockRepo, prometheus)
+	_, err := repo.GetCompanyByID(context.Background(), id)
+	assert.Error(t, err)
+	_, err = repo.GetCompanyByID(context.Background(), id)
+	assert.Error(t, err)
+	assert.NoError(t, metrics.NewEventsWriter(mockWriter, prometheus).Write(context.Background(), 1, 2))
+
+	prometheus.CompanyMutated("create")
+	prometheus.ObserveHTTPRequest("GET", "/companies/:id", 404, 0)
+
+	body := scrape(t, prometheus)
+	assert.Contains(t, body, `db_query_duration_seconds_count{method="GetCompanyByID",status="error"} 1`)
+	assert.Contains(t, body, `db_query_duration_seconds_count{method="GetCompanyByID",status="not_found"} 1`)
+	assert.Contains(t, body, `kafka_writes_total{status="ok"} 1`)
+	assert.Contains(t, body, `kafka_written_messages_total 2`)
+	assert.Contains(t, body, `companies_mutations_total{operation="create"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/companies/:id",status="404"} 1`)
+}
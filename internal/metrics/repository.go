@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/google/uuid"
+)
+
+// Repository observes durations of calls to the wrapped repository. Every method of ports.Repository
+// is implemented explicitly, so methods added to the port aren't left unobserved.
+type Repository struct {
+	repo    ports.Repository
+	metrics *Prometheus
+}
+
+var _ ports.Repository = (*Repository)(nil)
+
+func NewRepository(repo ports.Repository, metrics *Prometheus) *Repository {
+	return &Repository{
+		repo:    repo,
+		metrics: metrics,
+	}
+}
+
+func (r *Repository) observe(method string, start time.Time, err error) {
+	r.metrics.ObserveDBQuery(method, time.Since(start), err)
+}
+
+// Migrate isn't a query, so it isn't observed.
+func (r *Repository) Migrate() error {
+	return r.repo.Migrate()
+}
+
+func (r *Repository) GetCompanyByID(ctx context.Context, id uuid.UUID) (*domain.Company, error) {
+	start := time.Now()
+	company, err := r.repo.GetCompanyByID(ctx, id)
+	r.observe("GetCompanyByID", start, err)
+
+	return company, err
+}
+
+func (r *Repository) ListCompanies(ctx context.Context, params domain.ListParams) ([]*domain.Company, error) {
+	start := time.Now()
+	companies, err := r.repo.ListCompanies(ctx, params)
+	r.observe("ListCompanies", start, err)
+
+	return companies, err
+}
+
+func (r *Repository) CreateCompany(
+	ctx context.Context,
+	company *domain.Company,
+	event *ports.CompanyMutationEvent,
+) error {
+	start := time.Now()
+	err := r.repo.CreateCompany(ctx, company, event)
+	r.observe("CreateCompany", start, err)
+
+	return err
+}
+
+func (r *Repository) UpdateCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	version int,
+	patch domain.CompanyPatch,
+	event *ports.CompanyMutationEvent,
+) error {
+	start := time.Now()
+	err := r.repo.UpdateCompany(ctx, id, version, patch, event)
+	r.observe("UpdateCompany", start, err)
+
+	return err
+}
+
+func (r *Repository) ApplyBatch(
+	ctx context.Context,
+	mode string,
+	dryRun bool,
+	operations []*ports.BatchOperation,
+) ([]*domain.BatchResult, error) {
+	start := time.Now()
+	results, err := r.repo.ApplyBatch(ctx, mode, dryRun, operations)
+	r.observe("ApplyBatch", start, err)
+
+	return results, err
+}
+
+func (r *Repository) DeleteCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	version int,
+	event *ports.CompanyMutationEvent,
+) error {
+	start := time.Now()
+	err := r.repo.DeleteCompany(ctx, id, version, event)
+	r.observe("DeleteCompany", start, err)
+
+	return err
+}
+
+func (r *Repository) RestoreCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	event *ports.CompanyMutationEvent,
+) (*domain.Company, error) {
+	start := time.Now()
+	company, err := r.repo.RestoreCompany(ctx, id, event)
+	r.observe("RestoreCompany", start, err)
+
+	return company, err
+}
+
+func (r *Repository) ListPurgeableCompanies(
+	ctx context.Context,
+	deletedBefore time.Time,
+	limit int,
+) ([]uuid.UUID, error) {
+	start := time.Now()
+	ids, err := r.repo.ListPurgeableCompanies(ctx, deletedBefore, limit)
+	r.observe("ListPurgeableCompanies", start, err)
+
+	return ids, err
+}
+
+func (r *Repository) PurgeCompany(ctx context.Context, id uuid.UUID, event *ports.CompanyMutationEvent) error {
+	start := time.Now()
+	err := r.repo.PurgeCompany(ctx, id, event)
+	r.observe("PurgeCompany", start, err)
+
+	return err
+}
+
+func (r *Repository) GetCompanyHistory(ctx context.Context, id uuid.UUID) ([]*domain.CompanyVersion, error) {
+	start := time.Now()
+	versions, err := r.repo.GetCompanyHistory(ctx, id)
+	r.observe("GetCompanyHistory", start, err)
+
+	return versions, err
+}
+
+func (r *Repository) StoreOutbox(ctx context.Context, events ...*ports.CompanyMutationEvent) error {
+	start := time.Now()
+	err := r.repo.StoreOutbox(ctx, events...)
+	r.observe("StoreOutbox", start, err)
+
+	return err
+}
+
+func (r *Repository) FetchOutbox(ctx context.Context, limit int) ([]*ports.OutboxMessage, error) {
+	start := time.Now()
+	messages, err := r.repo.FetchOutbox(ctx, limit)
+	r.observe("FetchOutbox", start, err)
+
+	return messages, err
+}
+
+func (r *Repository) DeleteOutbox(ctx context.Context, ids ...int64) error {
+	start := time.Now()
+	err := r.repo.DeleteOutbox(ctx, ids...)
+	r.observe("DeleteOutbox", start, err)
+
+	return err
+}
+
+func (r *Repository) RescheduleOutbox(ctx context.Context, id int64, nextAttemptAt time.Time, reason string) error {
+	start := time.Now()
+	err := r.repo.RescheduleOutbox(ctx, id, nextAttemptAt, reason)
+	r.observe("RescheduleOutbox", start, err)
+
+	return err
+}
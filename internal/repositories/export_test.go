@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StoreOutboxPayload stores the encoded event in the outbox as is, the way events stored
+// by earlier versions of the app are read.
+func (m *Memory) StoreOutboxPayload(companyID uuid.UUID, payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastOutboxID++
+	m.outbox = append(m.outbox, &memoryOutboxMessage{
+		id:            m.lastOutboxID,
+		companyID:     companyID,
+		payload:       payload,
+		nextAttemptAt: time.Now(),
+	})
+}
+
+// DeadLetters returns the reasons outbox messages were moved to the dead letters.
+func (m *Memory) DeadLetters() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reasons := make([]string, 0, len(m.deadLetters))
+	for _, deadLetter := range m.deadLetters {
+		reasons = append(reasons, deadLetter.reason)
+	}
+
+	return reasons
+}
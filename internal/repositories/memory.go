@@ -0,0 +1,693 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Memory is a thread-safe repository keeping everything in memory, so the app is able to run without
+// a database. It follows the semantics of Postgres: names are unique among active companies, deleted
+// companies are kept until purged, mutations record history and store events in the outbox.
+// Data is lost once the app stops.
+type Memory struct {
+	mu sync.Mutex
+
+	companies       map[uuid.UUID]*memoryCompany
+	history         map[uuid.UUID][]*memoryVersion
+	outbox          []*memoryOutboxMessage
+	lastOutboxID    int64
+	deadLetters     []*memoryDeadLetter
+	idempotencyKeys map[string]*memoryIdempotencyRecord
+
+	logger *zap.Logger
+}
+
+type memoryCompany struct {
+	company   domain.Company
+	deletedAt time.Time
+}
+
+func (c *memoryCompany) deleted() bool {
+	return !c.deletedAt.IsZero()
+}
+
+type memoryVersion struct {
+	operation string
+	actor     string
+	changedAt time.Time
+	before    *domain.Company
+	after     *domain.Company
+}
+
+// memoryOutboxMessage keeps the event serialized, so relayed events look the same as the ones read from Postgres.
+type memoryOutboxMessage struct {
+	id            int64
+	companyID     uuid.UUID
+	payload       []byte
+	traceContext  map[string]string
+	attempts      int
+	nextAttemptAt time.Time
+}
+
+// memoryDeadLetter is the outbox message which can't be relayed.
+type memoryDeadLetter struct {
+	message *memoryOutboxMessage
+	reason  string
+}
+
+func NewMemory(logger *zap.Logger) *Memory {
+	return &Memory{
+		companies:       make(map[uuid.UUID]*memoryCompany),
+		history:         make(map[uuid.UUID][]*memoryVersion),
+		idempotencyKeys: make(map[string]*memoryIdempotencyRecord),
+		logger:          logger.Named("memory"),
+	}
+}
+
+// Migrate does nothing, there is no schema to migrate.
+func (m *Memory) Migrate() error {
+	return nil
+}
+
+func (m *Memory) GetCompanyByID(_ context.Context, id uuid.UUID) (*domain.Company, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.companies[id]
+	if !ok || stored.deleted() {
+		return nil, domain.NewCompanyNotFoundError(id)
+	}
+
+	company := stored.company
+
+	return &company, nil
+}
+
+func (m *Memory) ListCompanies(_ context.Context, params domain.ListParams) ([]*domain.Company, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	companies := make([]*domain.Company, 0, len(m.companies))
+
+	for _, stored := range m.companies {
+		if stored.deleted() || !matchesFilter(&stored.company, params.Filter) {
+			continue
+		}
+
+		if params.After != nil {
+			cmp := compareCompanyKeys(stored.company.Name, stored.company.ID, params.After.Name, params.After.ID)
+			if params.Descending && cmp >= 0 || !params.Descending && cmp <= 0 {
+				continue
+			}
+		}
+
+		company := stored.company
+		companies = append(companies, &company)
+	}
+
+	sort.Slice(companies, func(i, j int) bool {
+		cmp := compareCompanyKeys(companies[i].Name, companies[i].ID, companies[j].Name, companies[j].ID)
+		if params.Descending {
+			return cmp > 0
+		}
+
+		return cmp < 0
+	})
+
+	if len(companies) > params.Limit {
+		companies = companies[:params.Limit]
+	}
+
+	return companies, nil
+}
+
+func (m *Memory) CreateCompany(ctx context.Context, company *domain.Company, event *ports.CompanyMutationEvent) error {
+	return m.inTx(func(tx *memoryTx) error {
+		return m.createCompany(ctx, tx, company, event)
+	})
+}
+
+func (m *Memory) UpdateCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	version int,
+	patch domain.CompanyPatch,
+	event *ports.CompanyMutationEvent,
+) error {
+	return m.inTx(func(tx *memoryTx) error {
+		_, err := m.updateCompany(ctx, tx, id, version, patch, event)
+
+		return err
+	})
+}
+
+func (m *Memory) DeleteCompany(ctx context.Context, id uuid.UUID, version int, event *ports.CompanyMutationEvent) error {
+	return m.inTx(func(tx *memoryTx) error {
+		return m.deleteCompany(ctx, tx, id, version, event)
+	})
+}
+
+// ApplyBatch applies the operations while holding the lock, changes of failed operations are undone
+// the same way Postgres rolls back the transaction of atomic batches and savepoints of best-effort ones.
+func (m *Memory) ApplyBatch(
+	ctx context.Context,
+	mode string,
+	dryRun bool,
+	operations []*ports.BatchOperation,
+) ([]*domain.BatchResult, error) {
+	results := make([]*domain.BatchResult, len(operations))
+
+	err := m.inTx(func(tx *memoryTx) error {
+		for i, operation := range operations {
+			savepoint := len(tx.undo)
+
+			company, err := m.applyBatchOperation(ctx, tx, operation)
+			if err != nil && !isCompanyError(err) {
+				return err
+			}
+
+			results[i] = &domain.BatchResult{Company: company, Err: err}
+
+			if err != nil {
+				if mode == domain.BatchAtomic {
+					return errBatchFailed
+				}
+
+				tx.rollbackTo(savepoint)
+			}
+		}
+
+		if dryRun {
+			return errBatchDryRun
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBatchDryRun) {
+		return results, nil
+	}
+
+	if errors.Is(err, errBatchFailed) {
+		for i, result := range results {
+			if result == nil || result.Err == nil {
+				results[i] = &domain.BatchResult{Err: domain.ErrBatchAborted}
+			}
+		}
+
+		return results, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (m *Memory) applyBatchOperation(
+	ctx context.Context,
+	tx *memoryTx,
+	operation *ports.BatchOperation,
+) (*domain.Company, error) {
+	switch operation.Operation {
+	case domain.OperationCreate:
+		company := *operation.Company
+		if err := m.createCompany(ctx, tx, &company, operation.Event); err != nil {
+			return nil, err
+		}
+
+		return &company, nil
+	case domain.OperationUpdate:
+		return m.updateCompany(ctx, tx, operation.ID, operation.Version, operation.Patch, operation.Event)
+	case domain.OperationDelete:
+		return nil, m.deleteCompany(ctx, tx, operation.ID, operation.Version, operation.Event)
+	default:
+		return nil, fmt.Errorf("apply batch: unsupported operation \"%s\"", operation.Operation)
+	}
+}
+
+func (m *Memory) RestoreCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	event *ports.CompanyMutationEvent,
+) (*domain.Company, error) {
+	var company domain.Company
+
+	err := m.inTx(func(tx *memoryTx) error {
+		stored, ok := m.companies[id]
+		if !ok || !stored.deleted() {
+			return domain.NewCompanyNotFoundError(id)
+		}
+
+		restored := *stored
+		restored.deletedAt = time.Time{}
+		restored.company.Version++
+		company = restored.company
+
+		if err := m.putCompany(tx, &restored); err != nil {
+			return err
+		}
+
+		if err := m.insertHistory(ctx, tx, domain.OperationRestore, id, nil, &company); err != nil {
+			return err
+		}
+
+		return m.insertOutbox(ctx, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &company, nil
+}
+
+func (m *Memory) ListPurgeableCompanies(_ context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purgeable := make([]*memoryCompany, 0)
+
+	for _, stored := range m.companies {
+		if stored.deleted() && stored.deletedAt.Before(deletedBefore) {
+			purgeable = append(purgeable, stored)
+		}
+	}
+
+	sort.Slice(purgeable, func(i, j int) bool {
+		return purgeable[i].deletedAt.Before(purgeable[j].deletedAt)
+	})
+
+	if len(purgeable) > limit {
+		purgeable = purgeable[:limit]
+	}
+
+	ids := make([]uuid.UUID, 0, len(purgeable))
+	for _, stored := range purgeable {
+		ids = append(ids, stored.company.ID)
+	}
+
+	return ids, nil
+}
+
+// PurgeCompany permanently removes the deleted company, its history is preserved.
+func (m *Memory) PurgeCompany(ctx context.Context, id uuid.UUID, event *ports.CompanyMutationEvent) error {
+	return m.inTx(func(tx *memoryTx) error {
+		stored, ok := m.companies[id]
+		if !ok || !stored.deleted() {
+			return domain.NewCompanyNotFoundError(id)
+		}
+
+		delete(m.companies, id)
+		tx.undo = append(tx.undo, func() {
+			m.companies[id] = stored
+		})
+
+		before := stored.company
+		if err := m.insertHistory(ctx, tx, domain.OperationPurge, id, &before, nil); err != nil {
+			return err
+		}
+
+		return m.insertOutbox(ctx, tx, event)
+	})
+}
+
+func (m *Memory) GetCompanyHistory(_ context.Context, id uuid.UUID) ([]*domain.CompanyVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var history []*domain.CompanyVersion
+
+	for _, recorded := range m.history[id] {
+		history = append(history, &domain.CompanyVersion{
+			Version:   domain.ChangedVersion(recorded.operation, recorded.before, recorded.after),
+			Operation: recorded.operation,
+			Actor:     recorded.actor,
+			ChangedAt: recorded.changedAt,
+			Before:    copyCompany(recorded.before),
+			After:     copyCompany(recorded.after),
+		})
+	}
+
+	return history, nil
+}
+
+func (m *Memory) StoreOutbox(ctx context.Context, events ...*ports.CompanyMutationEvent) error {
+	return m.inTx(func(tx *memoryTx) error {
+		for _, event := range events {
+			if err := m.insertOutbox(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FetchOutbox claims the oldest message of every company which is due for delivery.
+// Messages which can't be decoded are moved to the dead letters instead of being returned.
+func (m *Memory) FetchOutbox(_ context.Context, limit int) ([]*ports.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	companies := make(map[uuid.UUID]struct{})
+	messages := make([]*ports.OutboxMessage, 0, limit)
+	outbox := make([]*memoryOutboxMessage, 0, len(m.outbox))
+
+	for _, s := range m.outbox {
+		if _, ok := companies[s.companyID]; ok || len(messages) == limit || s.nextAttemptAt.After(now) {
+			companies[s.companyID] = struct{}{}
+			outbox = append(outbox, s)
+
+			continue
+		}
+
+		message := &ports.OutboxMessage{
+			ID:       s.id,
+			Event:    new(ports.CompanyMutationEvent),
+			Attempts: s.attempts,
+		}
+
+		companies[s.companyID] = struct{}{}
+
+		if err := json.Unmarshal(s.payload, message.Event); err != nil {
+			m.logger.Error("fetch outbox: error deserializing event", zap.Int64("id", s.id), zap.Error(err))
+			m.deadLetters = append(m.deadLetters, &memoryDeadLetter{message: s, reason: err.Error()})
+
+			continue
+		}
+
+		outbox = append(outbox, s)
+
+		message.Event.TraceContext = s.traceContext
+		s.nextAttemptAt = now.Add(outboxLease)
+		messages = append(messages, message)
+	}
+
+	m.outbox = outbox
+
+	return messages, nil
+}
+
+func (m *Memory) DeleteOutbox(_ context.Context, ids ...int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		deleted[id] = struct{}{}
+	}
+
+	outbox := make([]*memoryOutboxMessage, 0, len(m.outbox))
+
+	for _, message := range m.outbox {
+		if _, ok := deleted[message.id]; !ok {
+			outbox = append(outbox, message)
+		}
+	}
+
+	m.outbox = outbox
+
+	return nil
+}
+
+func (m *Memory) RescheduleOutbox(_ context.Context, id int64, nextAttemptAt time.Time, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, message := range m.outbox {
+		if message.id == id {
+			message.attempts++
+			message.nextAttemptAt = nextAttemptAt
+		}
+	}
+
+	return nil
+}
+
+// Ping always succeeds, the storage is available as long as the app runs.
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
+
+func (m *Memory) createCompany(
+	ctx context.Context,
+	tx *memoryTx,
+	company *domain.Company,
+	event *ports.CompanyMutationEvent,
+) error {
+	if company.ID == uuid.Nil {
+		company.SetID()
+	}
+
+	company.Version = 1
+
+	if _, ok := m.companies[company.ID]; ok {
+		return fmt.Errorf("create company: company %s already exists", company.ID)
+	}
+
+	if err := m.putCompany(tx, &memoryCompany{company: *company}); err != nil {
+		return err
+	}
+
+	if err := m.insertHistory(ctx, tx, domain.OperationCreate, company.ID, nil, company); err != nil {
+		return err
+	}
+
+	return m.insertOutbox(ctx, tx, event)
+}
+
+// updateCompany sets the fields changed by the patch and returns the updated company.
+func (m *Memory) updateCompany(
+	ctx context.Context,
+	tx *memoryTx,
+	id uuid.UUID,
+	version int,
+	patch domain.CompanyPatch,
+	event *ports.CompanyMutationEvent,
+) (*domain.Company, error) {
+	stored, err := m.getCompanyForUpdate(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	before, after := stored.company, stored.company
+	patch.Apply(&after)
+	after.Version++
+
+	if err := m.putCompany(tx, &memoryCompany{company: after}); err != nil {
+		return nil, err
+	}
+
+	if err := m.insertHistory(ctx, tx, domain.OperationUpdate, id, &before, &after); err != nil {
+		return nil, err
+	}
+
+	if err := m.insertOutbox(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+func (m *Memory) deleteCompany(
+	ctx context.Context,
+	tx *memoryTx,
+	id uuid.UUID,
+	version int,
+	event *ports.CompanyMutationEvent,
+) error {
+	stored, err := m.getCompanyForUpdate(id, version)
+	if err != nil {
+		return err
+	}
+
+	before := stored.company
+	deleted := &memoryCompany{company: stored.company, deletedAt: time.Now()}
+	deleted.company.Version++
+
+	if err := m.putCompany(tx, deleted); err != nil {
+		return err
+	}
+
+	if err := m.insertHistory(ctx, tx, domain.OperationDelete, id, &before, nil); err != nil {
+		return err
+	}
+
+	return m.insertOutbox(ctx, tx, event)
+}
+
+// getCompanyForUpdate returns the active company, checking its version the same way Postgres does.
+func (m *Memory) getCompanyForUpdate(id uuid.UUID, version int) (*memoryCompany, error) {
+	stored, ok := m.companies[id]
+	if !ok || stored.deleted() {
+		return nil, domain.NewCompanyNotFoundError(id)
+	}
+
+	if version != 0 && stored.company.Version != version {
+		return nil, domain.NewVersionMismatchError(id, version, stored.company.Version)
+	}
+
+	return stored, nil
+}
+
+// putCompany stores the company, enforcing unique names of active companies.
+func (m *Memory) putCompany(tx *memoryTx, stored *memoryCompany) error {
+	id := stored.company.ID
+
+	if !stored.deleted() && m.nameTaken(stored.company.Name, id) {
+		return domain.NewNameAlreadyTakenError(stored.company.Name)
+	}
+
+	previous, existed := m.companies[id]
+	m.companies[id] = stored
+
+	tx.undo = append(tx.undo, func() {
+		if existed {
+			m.companies[id] = previous
+		} else {
+			delete(m.companies, id)
+		}
+	})
+
+	return nil
+}
+
+// nameTaken reports whether an active company other than the given one has the name.
+func (m *Memory) nameTaken(name string, id uuid.UUID) bool {
+	for _, stored := range m.companies {
+		if stored.company.ID != id && !stored.deleted() && stored.company.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// insertHistory records a company change as a part of the mutation.
+// The actor is taken from the context, see domain.WithActor.
+func (m *Memory) insertHistory(
+	ctx context.Context,
+	tx *memoryTx,
+	operation string,
+	id uuid.UUID,
+	before, after *domain.Company,
+) error {
+	m.history[id] = append(m.history[id], &memoryVersion{
+		operation: operation,
+		actor:     domain.ActorFromContext(ctx),
+		changedAt: time.Now(),
+		before:    copyCompany(before),
+		after:     copyCompany(after),
+	})
+
+	tx.undo = append(tx.undo, func() {
+		m.history[id] = m.history[id][:len(m.history[id])-1]
+		if len(m.history[id]) == 0 {
+			delete(m.history, id)
+		}
+	})
+
+	return nil
+}
+
+// insertOutbox stores the event together with the trace context of the mutation.
+func (m *Memory) insertOutbox(ctx context.Context, tx *memoryTx, event *ports.CompanyMutationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("insert outbox: error serializing event: %w", err)
+	}
+
+	m.lastOutboxID++
+	m.outbox = append(m.outbox, &memoryOutboxMessage{
+		id:            m.lastOutboxID,
+		companyID:     event.CompanyID,
+		payload:       payload,
+		traceContext:  tracing.Inject(ctx),
+		nextAttemptAt: time.Now(),
+	})
+
+	tx.undo = append(tx.undo, func() {
+		m.outbox = m.outbox[:len(m.outbox)-1]
+	})
+
+	return nil
+}
+
+// memoryTx collects functions undoing the changes made within the transaction.
+type memoryTx struct {
+	undo []func()
+}
+
+// rollbackTo undoes the changes made after the given amount of changes was reached.
+func (tx *memoryTx) rollbackTo(savepoint int) {
+	for i := len(tx.undo) - 1; i >= savepoint; i-- {
+		tx.undo[i]()
+	}
+
+	tx.undo = tx.undo[:savepoint]
+}
+
+// inTx runs fn while holding the lock and undoes its changes if it fails.
+// Errors returned by fn are passed through as is.
+func (m *Memory) inTx(fn func(tx *memoryTx) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := new(memoryTx)
+	if err := fn(tx); err != nil {
+		tx.rollbackTo(0)
+
+		return err
+	}
+
+	return nil
+}
+
+// matchesFilter checks the filter the same way ListCompanies of Postgres does, names are compared case-sensitively.
+func matchesFilter(company *domain.Company, filter domain.CompanyFilter) bool {
+	switch {
+	case filter.Type != nil && company.Type != *filter.Type,
+		filter.Registered != nil && company.Registered != *filter.Registered,
+		filter.MinEmployeeCnt != nil && company.EmployeeCnt < *filter.MinEmployeeCnt,
+		filter.MaxEmployeeCnt != nil && company.EmployeeCnt > *filter.MaxEmployeeCnt,
+		!strings.HasPrefix(company.Name, filter.NamePrefix):
+		return false
+	default:
+		return true
+	}
+}
+
+// compareCompanyKeys compares (name, id) pairs companies are ordered by. Names are compared byte-wise,
+// which matches Postgres ordering under the C collation.
+func compareCompanyKeys(nameA string, idA uuid.UUID, nameB string, idB uuid.UUID) int {
+	if cmp := strings.Compare(nameA, nameB); cmp != 0 {
+		return cmp
+	}
+
+	return bytes.Compare(idA[:], idB[:])
+}
+
+func copyCompany(company *domain.Company) *domain.Company {
+	if company == nil {
+		return nil
+	}
+
+	c := *company
+
+	return &c
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+)
+
+type memoryIdempotencyRecord struct {
+	record    ports.IdempotencyRecord
+	expiresAt time.Time
+}
+
+func (m *Memory) ReserveIdempotencyKey(
+	_ context.Context,
+	key, fingerprint string,
+	lockTimeout time.Duration,
+) (*ports.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// expired records are taken over as if they didn't exist
+	if stored, ok := m.idempotencyKeys[key]; ok && !stored.expiresAt.Before(now) {
+		record := stored.record
+
+		return &record, false, nil
+	}
+
+	m.idempotencyKeys[key] = &memoryIdempotencyRecord{
+		record:    ports.IdempotencyRecord{Key: key, Fingerprint: fingerprint},
+		expiresAt: now.Add(lockTimeout),
+	}
+
+	return &ports.IdempotencyRecord{Key: key, Fingerprint: fingerprint}, true, nil
+}
+
+func (m *Memory) CompleteIdempotencyKey(
+	_ context.Context,
+	key string,
+	ttl time.Duration,
+	statusCode int,
+	header map[string]string,
+	body []byte,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.idempotencyKeys[key]
+	if !ok {
+		return nil
+	}
+
+	stored.record.Completed = true
+	stored.record.StatusCode = statusCode
+	stored.record.Header = header
+	stored.record.Body = body
+	stored.expiresAt = time.Now().Add(ttl)
+
+	return nil
+}
+
+func (m *Memory) ReleaseIdempotencyKey(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.idempotencyKeys[key]; ok && !stored.record.Completed {
+		delete(m.idempotencyKeys, key)
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteExpiredIdempotencyKeys(context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	var deleted int64
+
+	for key, stored := range m.idempotencyKeys {
+		if stored.expiresAt.Before(now) {
+			delete(m.idempotencyKeys, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
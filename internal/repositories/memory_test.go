@@ -0,0 +1,220 @@
+package repositories_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports/portstest"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCompany(name string) *domain.Company {
+	return &domain.Company{Name: name, EmployeeCnt: 10, Type: "NonProfit"}
+}
+
+func newEvent(company *domain.Company) *ports.CompanyMutationEvent {
+	return ports.NewCompanyMutationEvent(context.Background(), "test-app", company.ID, ports.NewCompanyCreatedData(company))
+}
+
+// drainOutbox removes outbox messages which are due for delivery and returns their amount.
+func drainOutbox(t *testing.T, repo *repositories.Memory) int {
+	t.Helper()
+
+	drained := 0
+
+	for {
+		messages, err := repo.FetchOutbox(context.Background(), 100)
+		require.NoError(t, err)
+
+		if len(messages) == 0 {
+			return drained
+		}
+
+		for _, message := range messages {
+			require.NoError(t, repo.DeleteOutbox(context.Background(), message.ID))
+		}
+
+		drained += len(messages)
+	}
+}
+
+// testUndecodableOutbox checks that outbox messages which can't be decoded don't block the relay. store has
+// to store the encoded event in the outbox as is, deadLetters has to return the amount of dead letters.
+func testUndecodableOutbox(
+	t *testing.T,
+	repo ports.Repository,
+	store func(companyID uuid.UUID, payload []byte),
+	deadLetters func() int,
+) {
+	t.Helper()
+
+	ctx := context.Background()
+	legacyID, brokenID := uuid.New(), uuid.New()
+
+	// events stored before schema versions were introduced have no version
+	store(legacyID, []byte(`{
+		"id": "`+uuid.NewString()+`",
+		"name": "CompanyDeleted",
+		"time": "2023-05-01T12:30:00Z",
+		"producer": "test-app",
+		"company_id": "`+legacyID.String()+`",
+		"data": {"id": "`+legacyID.String()+`"}
+	}`))
+	store(brokenID, []byte(`{"id": "`+uuid.NewString()+`", "name": "CompanyArchived", "company_id": "`+brokenID.String()+`"}`))
+
+	payload, err := json.Marshal(ports.NewCompanyMutationEvent(ctx, "test-app", brokenID, &ports.CompanyDeletedData{ID: brokenID}))
+	require.NoError(t, err)
+	store(brokenID, payload)
+
+	messages, err := repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err, "undecodable messages mustn't fail the fetch")
+	require.Len(t, messages, 1)
+	assert.Equal(t, legacyID, messages[0].Event.CompanyID)
+	assert.Equal(t, 1, messages[0].Event.SchemaVersion, "events without versions have the first one")
+	assert.Equal(t, &ports.CompanyDeletedData{ID: legacyID}, messages[0].Event.Data)
+	assert.Equal(t, 1, deadLetters(), "undecodable messages have to be moved to the dead letters")
+
+	require.NoError(t, repo.DeleteOutbox(ctx, messages[0].ID))
+
+	messages, err = repo.FetchOutbox(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1, "later messages of the company mustn't wait for the dead letter")
+	assert.Equal(t, brokenID, messages[0].Event.CompanyID)
+}
+
+func TestMemory_Conformance(t *testing.T) {
+	portstest.RunRepository(t, func(t *testing.T) ports.Repository {
+		return repositories.NewMemory(zap.NewNop())
+	})
+}
+
+func TestMemory_Companies(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewMemory(zap.NewNop())
+
+	first, second := newCompany("first"), newCompany("second")
+	require.NoError(t, repo.CreateCompany(ctx, first, newEvent(first)))
+	require.NoError(t, repo.CreateCompany(ctx, second, newEvent(second)))
+	assert.Equal(t, 1, first.Version)
+
+	var nameTakenErr *domain.NameAlreadyTakenError
+	assert.ErrorAs(t, repo.CreateCompany(ctx, newCompany("first"), newEvent(first)), &nameTakenErr)
+
+	name, employeeCnt := "second", 20
+	err := repo.UpdateCompany(ctx, first.ID, 1, domain.CompanyPatch{Name: &name}, newEvent(first))
+	assert.ErrorAs(t, err, &nameTakenErr)
+
+	var versionMismatchErr *domain.VersionMismatchError
+	err = repo.UpdateCompany(ctx, first.ID, 2, domain.CompanyPatch{EmployeeCnt: &employeeCnt}, newEvent(first))
+	assert.ErrorAs(t, err, &versionMismatchErr)
+
+	require.NoError(t, repo.UpdateCompany(ctx, first.ID, 1, domain.CompanyPatch{EmployeeCnt: &employeeCnt}, newEvent(first)))
+
+	updated, err := repo.GetCompanyByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20, updated.EmployeeCnt)
+	assert.Equal(t, "first", updated.Name)
+	assert.Equal(t, 2, updated.Version)
+
+	// names of deleted companies are free until the company is restored
+	require.NoError(t, repo.DeleteCompany(ctx, first.ID, 0, newEvent(first)))
+
+	var notFoundErr *domain.CompanyNotFoundError
+	_, err = repo.GetCompanyByID(ctx, first.ID)
+	assert.ErrorAs(t, err, &notFoundErr)
+
+	third := newCompany("first")
+	require.NoError(t, repo.CreateCompany(ctx, third, newEvent(third)))
+
+	_, err = repo.RestoreCompany(ctx, first.ID, newEvent(first))
+	assert.ErrorAs(t, err, &nameTakenErr)
+
+	page, err := repo.ListCompanies(ctx, domain.ListParams{Limit: 10, After: domain.NewListCursor(third)})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, second.ID, page[0].ID)
+
+	history, err := repo.GetCompanyHistory(ctx, first.ID)
+	require.NoError(t, err)
+
+	operations := make([]string, 0, len(history))
+	for _, version := range history {
+		operations = append(operations, version.Operation)
+	}
+
+	assert.Equal(t, []string{domain.OperationCreate, domain.OperationUpdate, domain.OperationDelete}, operations)
+
+	// failed mutations don't store events
+	assert.Equal(t, 5, drainOutbox(t, repo))
+}
+
+func TestMemory_UndecodableOutbox(t *testing.T) {
+	repo := repositories.NewMemory(zap.NewNop())
+
+	testUndecodableOutbox(t, repo, repo.StoreOutboxPayload, func() int {
+		return len(repo.DeadLetters())
+	})
+}
+
+func TestMemory_ApplyBatch(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewMemory(zap.NewNop())
+
+	existing := newCompany("existing")
+	require.NoError(t, repo.CreateCompany(ctx, existing, newEvent(existing)))
+
+	created, duplicate := newCompany("created"), newCompany("existing")
+	operations := []*ports.BatchOperation{
+		{BatchOperation: domain.BatchOperation{Operation: domain.OperationCreate, Company: created}, Event: newEvent(created)},
+		{BatchOperation: domain.BatchOperation{Operation: domain.OperationCreate, Company: duplicate}, Event: newEvent(duplicate)},
+	}
+
+	results, err := repo.ApplyBatch(ctx, domain.BatchAtomic, false, operations)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, domain.ErrBatchAborted)
+
+	var nameTakenErr *domain.NameAlreadyTakenError
+	assert.ErrorAs(t, results[1].Err, &nameTakenErr)
+
+	companies, err := repo.ListCompanies(ctx, domain.ListParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, companies, 1, "atomic batch has to be rolled back")
+
+	results, err = repo.ApplyBatch(ctx, domain.BatchBestEffort, false, operations)
+	require.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorAs(t, results[1].Err, &nameTakenErr)
+
+	companies, err = repo.ListCompanies(ctx, domain.ListParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, companies, 2)
+
+	assert.Equal(t, 2, drainOutbox(t, repo))
+}
+
+func TestMemory_Purge(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewMemory(zap.NewNop())
+
+	company := newCompany("purged")
+	require.NoError(t, repo.CreateCompany(ctx, company, newEvent(company)))
+	require.NoError(t, repo.DeleteCompany(ctx, company.ID, 0, newEvent(company)))
+
+	ids, err := repo.ListPurgeableCompanies(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{company.ID}, ids)
+
+	require.NoError(t, repo.PurgeCompany(ctx, company.ID, newEvent(company)))
+
+	var notFoundErr *domain.CompanyNotFoundError
+	_, err = repo.RestoreCompany(ctx, company.ID, newEvent(company))
+	assert.ErrorAs(t, err, &notFoundErr)
+}
@@ -7,8 +7,10 @@ package repositories
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	ports "github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 )
@@ -37,37 +39,93 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyBatch mocks base method.
+func (m *MockRepository) ApplyBatch(ctx context.Context, mode string, dryRun bool, operations []*ports.BatchOperation) ([]*domain.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBatch", ctx, mode, dryRun, operations)
+	ret0, _ := ret[0].([]*domain.BatchResult)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// ApplyBatch indicates an expected call of ApplyBatch.
+func (mr *MockRepositoryMockRecorder) ApplyBatch(ctx, mode, dryRun, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBatch", reflect.TypeOf((*MockRepository)(nil).ApplyBatch), ctx, mode, dryRun, operations)
+}
+
 // CreateCompany mocks base method.
-func (m *MockRepository) CreateCompany(ctx context.Context, company *domain.Company) error {
+func (m *MockRepository) CreateCompany(ctx context.Context, company *domain.Company, event *ports.CompanyMutationEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateCompany", ctx, company)
+	ret := m.ctrl.Call(m, "CreateCompany", ctx, company, event)
 	ret0, _ := ret[0].(error)
 
 	return ret0
 }
 
 // CreateCompany indicates an expected call of CreateCompany.
-func (mr *MockRepositoryMockRecorder) CreateCompany(ctx, company interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateCompany(ctx, company, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCompany", reflect.TypeOf((*MockRepository)(nil).CreateCompany), ctx, company)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCompany", reflect.TypeOf((*MockRepository)(nil).CreateCompany), ctx, company, event)
 }
 
 // DeleteCompany mocks base method.
-func (m *MockRepository) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+func (m *MockRepository) DeleteCompany(ctx context.Context, id uuid.UUID, version int, event *ports.CompanyMutationEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCompany", ctx, id)
+	ret := m.ctrl.Call(m, "DeleteCompany", ctx, id, version, event)
 	ret0, _ := ret[0].(error)
 
 	return ret0
 }
 
 // DeleteCompany indicates an expected call of DeleteCompany.
-func (mr *MockRepositoryMockRecorder) DeleteCompany(ctx, id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) DeleteCompany(ctx, id, version, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCompany", reflect.TypeOf((*MockRepository)(nil).DeleteCompany), ctx, id, version, event)
+}
+
+// DeleteOutbox mocks base method.
+func (m *MockRepository) DeleteOutbox(ctx context.Context, ids ...int64) error {
+	m.ctrl.T.Helper()
+
+	varargs := []interface{}{ctx}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+
+	ret := m.ctrl.Call(m, "DeleteOutbox", varargs...)
+	ret0, _ := ret[0].(error)
+
+	return ret0
+}
+
+// DeleteOutbox indicates an expected call of DeleteOutbox.
+func (mr *MockRepositoryMockRecorder) DeleteOutbox(ctx interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+
+	varargs := append([]interface{}{ctx}, ids...)
+
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOutbox", reflect.TypeOf((*MockRepository)(nil).DeleteOutbox), varargs...)
+}
+
+// FetchOutbox mocks base method.
+func (m *MockRepository) FetchOutbox(ctx context.Context, limit int) ([]*ports.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchOutbox", ctx, limit)
+	ret0, _ := ret[0].([]*ports.OutboxMessage)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// FetchOutbox indicates an expected call of FetchOutbox.
+func (mr *MockRepositoryMockRecorder) FetchOutbox(ctx, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCompany", reflect.TypeOf((*MockRepository)(nil).DeleteCompany), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchOutbox", reflect.TypeOf((*MockRepository)(nil).FetchOutbox), ctx, limit)
 }
 
-// GetCompanyById mocks base method.
+// GetCompanyByID mocks base method.
 func (m *MockRepository) GetCompanyByID(ctx context.Context, id uuid.UUID) (*domain.Company, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCompanyByID", ctx, id)
@@ -77,12 +135,60 @@ func (m *MockRepository) GetCompanyByID(ctx context.Context, id uuid.UUID) (*dom
 	return ret0, ret1
 }
 
-// GetCompanyById indicates an expected call of GetCompanyById.
-func (mr *MockRepositoryMockRecorder) GetCompanyById(ctx, id interface{}) *gomock.Call {
+// GetCompanyByID indicates an expected call of GetCompanyByID.
+func (mr *MockRepositoryMockRecorder) GetCompanyByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanyByID", reflect.TypeOf((*MockRepository)(nil).GetCompanyByID), ctx, id)
 }
 
+// GetCompanyHistory mocks base method.
+func (m *MockRepository) GetCompanyHistory(ctx context.Context, id uuid.UUID) ([]*domain.CompanyVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompanyHistory", ctx, id)
+	ret0, _ := ret[0].([]*domain.CompanyVersion)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// GetCompanyHistory indicates an expected call of GetCompanyHistory.
+func (mr *MockRepositoryMockRecorder) GetCompanyHistory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanyHistory", reflect.TypeOf((*MockRepository)(nil).GetCompanyHistory), ctx, id)
+}
+
+// ListCompanies mocks base method.
+func (m *MockRepository) ListCompanies(ctx context.Context, params domain.ListParams) ([]*domain.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCompanies", ctx, params)
+	ret0, _ := ret[0].([]*domain.Company)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// ListCompanies indicates an expected call of ListCompanies.
+func (mr *MockRepositoryMockRecorder) ListCompanies(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCompanies", reflect.TypeOf((*MockRepository)(nil).ListCompanies), ctx, params)
+}
+
+// ListPurgeableCompanies mocks base method.
+func (m *MockRepository) ListPurgeableCompanies(ctx context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPurgeableCompanies", ctx, deletedBefore, limit)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// ListPurgeableCompanies indicates an expected call of ListPurgeableCompanies.
+func (mr *MockRepositoryMockRecorder) ListPurgeableCompanies(ctx, deletedBefore, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPurgeableCompanies", reflect.TypeOf((*MockRepository)(nil).ListPurgeableCompanies), ctx, deletedBefore, limit)
+}
+
 // Migrate mocks base method.
 func (m *MockRepository) Migrate() error {
 	m.ctrl.T.Helper()
@@ -98,17 +204,87 @@ func (mr *MockRepositoryMockRecorder) Migrate() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockRepository)(nil).Migrate))
 }
 
+// PurgeCompany mocks base method.
+func (m *MockRepository) PurgeCompany(ctx context.Context, id uuid.UUID, event *ports.CompanyMutationEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeCompany", ctx, id, event)
+	ret0, _ := ret[0].(error)
+
+	return ret0
+}
+
+// PurgeCompany indicates an expected call of PurgeCompany.
+func (mr *MockRepositoryMockRecorder) PurgeCompany(ctx, id, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeCompany", reflect.TypeOf((*MockRepository)(nil).PurgeCompany), ctx, id, event)
+}
+
+// RescheduleOutbox mocks base method.
+func (m *MockRepository) RescheduleOutbox(ctx context.Context, id int64, nextAttemptAt time.Time, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleOutbox", ctx, id, nextAttemptAt, reason)
+	ret0, _ := ret[0].(error)
+
+	return ret0
+}
+
+// RescheduleOutbox indicates an expected call of RescheduleOutbox.
+func (mr *MockRepositoryMockRecorder) RescheduleOutbox(ctx, id, nextAttemptAt, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleOutbox", reflect.TypeOf((*MockRepository)(nil).RescheduleOutbox), ctx, id, nextAttemptAt, reason)
+}
+
+// RestoreCompany mocks base method.
+func (m *MockRepository) RestoreCompany(ctx context.Context, id uuid.UUID, event *ports.CompanyMutationEvent) (*domain.Company, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreCompany", ctx, id, event)
+	ret0, _ := ret[0].(*domain.Company)
+	ret1, _ := ret[1].(error)
+
+	return ret0, ret1
+}
+
+// RestoreCompany indicates an expected call of RestoreCompany.
+func (mr *MockRepositoryMockRecorder) RestoreCompany(ctx, id, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreCompany", reflect.TypeOf((*MockRepository)(nil).RestoreCompany), ctx, id, event)
+}
+
+// StoreOutbox mocks base method.
+func (m *MockRepository) StoreOutbox(ctx context.Context, events ...*ports.CompanyMutationEvent) error {
+	m.ctrl.T.Helper()
+
+	varargs := []interface{}{ctx}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+
+	ret := m.ctrl.Call(m, "StoreOutbox", varargs...)
+	ret0, _ := ret[0].(error)
+
+	return ret0
+}
+
+// StoreOutbox indicates an expected call of StoreOutbox.
+func (mr *MockRepositoryMockRecorder) StoreOutbox(ctx interface{}, events ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+
+	varargs := append([]interface{}{ctx}, events...)
+
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreOutbox", reflect.TypeOf((*MockRepository)(nil).StoreOutbox), varargs...)
+}
+
 // UpdateCompany mocks base method.
-func (m *MockRepository) UpdateCompany(ctx context.Context, id uuid.UUID, fieldsToUpdate map[string]any) error {
+func (m *MockRepository) UpdateCompany(ctx context.Context, id uuid.UUID, version int, patch domain.CompanyPatch, event *ports.CompanyMutationEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateCompany", ctx, id, fieldsToUpdate)
+	ret := m.ctrl.Call(m, "UpdateCompany", ctx, id, version, patch, event)
 	ret0, _ := ret[0].(error)
 
 	return ret0
 }
 
 // UpdateCompany indicates an expected call of UpdateCompany.
-func (mr *MockRepositoryMockRecorder) UpdateCompany(ctx, id, fieldsToUpdate interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) UpdateCompany(ctx, id, version, patch, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCompany", reflect.TypeOf((*MockRepository)(nil).UpdateCompany), ctx, id, fieldsToUpdate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCompany", reflect.TypeOf((*MockRepository)(nil).UpdateCompany), ctx, id, version, patch, event)
 }
@@ -4,13 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 
 	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/golang-migrate/migrate/v4"
@@ -19,10 +21,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
 const (
-	companyTable = "company"
+	companyTable   = "company"
+	companyColumns = "id, name, description, employee_cnt, registered, type, version"
 )
 
 type Postgres struct {
@@ -33,14 +37,20 @@ type Postgres struct {
 	maxPoolSize  int
 	connAttempts int
 	connTimeout  time.Duration
+	logger       *zap.Logger
 }
 
-func NewPostgres(dsn string, maxPoolSize, connAttempts, connTimeoutSeconds int) (*Postgres, error) {
+func NewPostgres(
+	dsn string,
+	maxPoolSize, connAttempts, connTimeoutSeconds int,
+	logger *zap.Logger,
+) (*Postgres, error) {
 	pg := Postgres{
 		dsn:          dsn,
 		maxPoolSize:  maxPoolSize,
 		connAttempts: connAttempts,
 		connTimeout:  time.Duration(int(time.Second) * connTimeoutSeconds),
+		logger:       logger.Named("postgres"),
 	}
 
 	pg.Builder = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
@@ -51,13 +61,15 @@ func NewPostgres(dsn string, maxPoolSize, connAttempts, connTimeoutSeconds int)
 	}
 
 	poolConfig.MaxConns = int32(pg.maxPoolSize)
+	poolConfig.ConnConfig.Tracer = tracing.NewQueryTracer()
+
 	for attempts := pg.connAttempts; attempts > 0; attempts-- {
 		pg.Pool, err = pgxpool.NewWithConfig(context.Background(), poolConfig)
 		if err == nil {
 			break
 		}
 
-		log.Printf("postgres: trying to connect to database instance, %d attempts left", attempts)
+		pg.logger.Info("trying to connect to database instance", zap.Int("attempts_left", attempts))
 		time.Sleep(pg.connTimeout)
 	}
 
@@ -65,40 +77,74 @@ func NewPostgres(dsn string, maxPoolSize, connAttempts, connTimeoutSeconds int)
 		return nil, fmt.Errorf("error while connecting to the database: %w", err)
 	}
 
-	log.Printf("postgres: connected successfully")
+	pg.logger.Info("connected successfully")
 
 	return &pg, nil
 }
 
 func (p Postgres) Migrate() error {
+	migration, err := p.newMigration()
+	if err != nil {
+		return err
+	}
+	defer migration.Close()
+
+	return p.logMigration("up", migration.Up())
+}
+
+// MigrateDown rolls back the given amount of the latest applied migrations.
+func (p Postgres) MigrateDown(steps int) error {
+	migration, err := p.newMigration()
+	if err != nil {
+		return err
+	}
+	defer migration.Close()
+
+	return p.logMigration("down", migration.Steps(-steps))
+}
+
+// MigrateTo applies or rolls back migrations until the given version is reached.
+func (p Postgres) MigrateTo(version uint) error {
+	migration, err := p.newMigration()
+	if err != nil {
+		return err
+	}
+	defer migration.Close()
+
+	return p.logMigration("to", migration.Migrate(version))
+}
+
+// newMigration connects to the database, retrying the same way the pool does.
+func (p Postgres) newMigration() (*migrate.Migrate, error) {
 	var migration *migrate.Migrate
 
 	var err error
 	for attempts := p.connAttempts; attempts > 0; attempts-- {
-		migration, err = migrate.New("file://migrations", p.dsn)
+		migration, err = migrate.New(migrationsURL, p.dsn)
 		if err == nil {
 			break
 		}
 
-		log.Printf("migrate: trying to connect to database instance, %d attempts left", attempts)
+		p.logger.Info("migrate: trying to connect to database instance", zap.Int("attempts_left", attempts))
 		time.Sleep(p.connTimeout)
 	}
 
 	if err != nil {
-		return fmt.Errorf("error while trying to migrate: database connection error: %s", err)
+		return nil, fmt.Errorf("error while trying to migrate: database connection error: %s", err)
 	}
 
-	err = migration.Up()
-	defer migration.Close()
+	return migration, nil
+}
 
+func (p Postgres) logMigration(direction string, err error) error {
 	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("migrate: up error: %w", err)
+		return fmt.Errorf("migrate: %s error: %w", direction, err)
 	}
 
 	if errors.Is(err, migrate.ErrNoChange) {
-		log.Printf("migrate: no change")
+		p.logger.Info("migrate: no change")
 	} else {
-		log.Printf("migrate: success")
+		p.logger.Info("migrate: success")
 	}
 
 	return nil
@@ -106,25 +152,15 @@ func (p Postgres) Migrate() error {
 
 func (p Postgres) GetCompanyByID(ctx context.Context, id uuid.UUID) (*domain.Company, error) {
 	query, args, err := p.Builder.
-		Select("id, name, description, employee_cnt, registered, type").
+		Select(companyColumns).
 		From(companyTable).
-		Where("id = ?", id).
+		Where("id = ? AND deleted_at IS NULL", id).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("get company: error building query: %w", err)
 	}
 
-	row := p.Pool.QueryRow(ctx, query, args...)
-	targetCompany := new(domain.Company)
-
-	err = row.Scan(
-		&targetCompany.ID,
-		&targetCompany.Name,
-		&targetCompany.Description,
-		&targetCompany.EmployeeCnt,
-		&targetCompany.Registered,
-		&targetCompany.Type,
-	)
+	targetCompany, err := scanCompany(p.Pool.QueryRow(ctx, query, args...))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.NewCompanyNotFoundError(id)
@@ -136,22 +172,231 @@ func (p Postgres) GetCompanyByID(ctx context.Context, id uuid.UUID) (*domain.Com
 	return targetCompany, nil
 }
 
-func (p Postgres) CreateCompany(ctx context.Context, company *domain.Company) error {
+func (p Postgres) ListCompanies(ctx context.Context, params domain.ListParams) ([]*domain.Company, error) {
+	listQueryBuilder := p.Builder.
+		Select(companyColumns).
+		From(companyTable).
+		Where("deleted_at IS NULL")
+
+	filter := params.Filter
+	if filter.Type != nil {
+		listQueryBuilder = listQueryBuilder.Where(squirrel.Eq{"type": *filter.Type})
+	}
+
+	if filter.Registered != nil {
+		listQueryBuilder = listQueryBuilder.Where(squirrel.Eq{"registered": *filter.Registered})
+	}
+
+	if filter.MinEmployeeCnt != nil {
+		listQueryBuilder = listQueryBuilder.Where(squirrel.GtOrEq{"employee_cnt": *filter.MinEmployeeCnt})
+	}
+
+	if filter.MaxEmployeeCnt != nil {
+		listQueryBuilder = listQueryBuilder.Where(squirrel.LtOrEq{"employee_cnt": *filter.MaxEmployeeCnt})
+	}
+
+	if filter.NamePrefix != "" {
+		listQueryBuilder = listQueryBuilder.Where(squirrel.Like{"name": escapeLike(filter.NamePrefix) + "%"})
+	}
+
+	order := "ASC"
+	if params.Descending {
+		order = "DESC"
+	}
+
+	if params.After != nil {
+		cmp := ">"
+		if params.Descending {
+			cmp = "<"
+		}
+
+		listQueryBuilder = listQueryBuilder.Where("(name, id) "+cmp+" (?, ?)", params.After.Name, params.After.ID)
+	}
+
+	query, args, err := listQueryBuilder.
+		OrderBy("name "+order, "id "+order).
+		Limit(uint64(params.Limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("list companies: error building query: %w", err)
+	}
+
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list companies: error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	companies := make([]*domain.Company, 0, params.Limit)
+
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list companies: error scanning row: %w", err)
+		}
+
+		companies = append(companies, company)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list companies: error reading rows: %w", err)
+	}
+
+	return companies, nil
+}
+
+func (p Postgres) CreateCompany(ctx context.Context, company *domain.Company, event *ports.CompanyMutationEvent) error {
+	return p.inTx(ctx, "create company", func(tx pgx.Tx) error {
+		return p.createCompany(ctx, tx, company, event)
+	})
+}
+
+func (p Postgres) UpdateCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	version int,
+	patch domain.CompanyPatch,
+	event *ports.CompanyMutationEvent,
+) error {
+	return p.inTx(ctx, "update company", func(tx pgx.Tx) error {
+		_, err := p.updateCompany(ctx, tx, id, version, patch, event)
+
+		return err
+	})
+}
+
+// DeleteCompany marks the company as deleted, it can be either restored or purged later.
+func (p Postgres) DeleteCompany(ctx context.Context, id uuid.UUID, version int, event *ports.CompanyMutationEvent) error {
+	return p.inTx(ctx, "delete company", func(tx pgx.Tx) error {
+		return p.deleteCompany(ctx, tx, id, version, event)
+	})
+}
+
+// ApplyBatch applies all operations within a single transaction, so their events are stored in the outbox
+// together. Operations of best-effort batches are applied within savepoints.
+func (p Postgres) ApplyBatch(
+	ctx context.Context,
+	mode string,
+	dryRun bool,
+	operations []*ports.BatchOperation,
+) ([]*domain.BatchResult, error) {
+	results := make([]*domain.BatchResult, len(operations))
+
+	err := p.inTx(ctx, "apply batch", func(tx pgx.Tx) error {
+		for i, operation := range operations {
+			var (
+				company *domain.Company
+				err     error
+			)
+
+			if mode == domain.BatchAtomic {
+				company, err = p.applyBatchOperation(ctx, tx, operation)
+			} else {
+				err = p.inSavepoint(ctx, tx, "apply batch", func(savepoint pgx.Tx) error {
+					company, err = p.applyBatchOperation(ctx, savepoint, operation)
+
+					return err
+				})
+			}
+
+			if err != nil && !isCompanyError(err) {
+				return err
+			}
+
+			results[i] = &domain.BatchResult{Company: company, Err: err}
+
+			if err != nil && mode == domain.BatchAtomic {
+				return errBatchFailed
+			}
+		}
+
+		if dryRun {
+			return errBatchDryRun
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errBatchDryRun) {
+		return results, nil
+	}
+
+	if errors.Is(err, errBatchFailed) {
+		for i, result := range results {
+			if result == nil || result.Err == nil {
+				results[i] = &domain.BatchResult{Err: domain.ErrBatchAborted}
+			}
+		}
+
+		return results, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+var (
+	// errBatchFailed rolls back the transaction of the atomic batch once one of its operations fails.
+	errBatchFailed = errors.New("batch operation failed")
+	// errBatchDryRun rolls back the transaction of the dry-run batch once all of its operations are applied.
+	errBatchDryRun = errors.New("batch is dry run")
+)
+
+func (p Postgres) applyBatchOperation(
+	ctx context.Context,
+	tx pgx.Tx,
+	operation *ports.BatchOperation,
+) (*domain.Company, error) {
+	switch operation.Operation {
+	case domain.OperationCreate:
+		company := *operation.Company
+		if err := p.createCompany(ctx, tx, &company, operation.Event); err != nil {
+			return nil, err
+		}
+
+		return &company, nil
+	case domain.OperationUpdate:
+		return p.updateCompany(ctx, tx, operation.ID, operation.Version, operation.Patch, operation.Event)
+	case domain.OperationDelete:
+		return nil, p.deleteCompany(ctx, tx, operation.ID, operation.Version, operation.Event)
+	default:
+		return nil, fmt.Errorf("apply batch: unsupported operation \"%s\"", operation.Operation)
+	}
+}
+
+func (p Postgres) createCompany(
+	ctx context.Context,
+	tx pgx.Tx,
+	company *domain.Company,
+	event *ports.CompanyMutationEvent,
+) error {
 	if company.ID == uuid.Nil {
 		company.SetID()
 	}
 
+	company.Version = 1
+
 	query, args, err := p.Builder.
 		Insert(companyTable).
-		Columns("id, name, description, employee_cnt, registered, type").
-		Values(company.ID, company.Name, company.Description, company.EmployeeCnt, company.Registered, company.Type).
+		Columns(companyColumns).
+		Values(
+			company.ID,
+			company.Name,
+			company.Description,
+			company.EmployeeCnt,
+			company.Registered,
+			company.Type,
+			company.Version,
+		).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("create company: error building query: %w", err)
 	}
 
-	_, err = p.Pool.Exec(ctx, query, args...)
-	if err != nil {
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
 			return domain.NewNameAlreadyTakenError(company.Name)
@@ -160,58 +405,328 @@ func (p Postgres) CreateCompany(ctx context.Context, company *domain.Company) er
 		return fmt.Errorf("create company: error executing query: %w", err)
 	}
 
-	return nil
+	if err := p.insertHistory(ctx, tx, domain.OperationCreate, company.ID, nil, company); err != nil {
+		return err
+	}
+
+	return p.insertOutbox(ctx, tx, event)
 }
 
-func (p Postgres) UpdateCompany(ctx context.Context, id uuid.UUID, fieldsToUpdate map[string]any) error {
-	updateQueryBuilder := p.Builder.Update(companyTable)
-	for field, val := range fieldsToUpdate {
+// updateCompany sets the fields changed by the patch and returns the updated company.
+func (p Postgres) updateCompany(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	version int,
+	patch domain.CompanyPatch,
+	event *ports.CompanyMutationEvent,
+) (*domain.Company, error) {
+	updateQueryBuilder := p.Builder.
+		Update(companyTable).
+		Set("version", squirrel.Expr("version + 1"))
+	for field, val := range patch.Fields() {
 		updateQueryBuilder = updateQueryBuilder.Set(field, val)
 	}
 
-	updateQueryBuilder = updateQueryBuilder.Where("id = ?", id)
+	updateQueryBuilder = updateQueryBuilder.
+		Where("id = ? AND deleted_at IS NULL", id).
+		Suffix("RETURNING " + companyColumns)
 
 	query, args, err := updateQueryBuilder.ToSql()
 	if err != nil {
-		return fmt.Errorf("update company: error building query: %w", err)
+		return nil, fmt.Errorf("update company: error building query: %w", err)
+	}
+
+	before, err := p.getCompanyForUpdate(ctx, tx, id, version)
+	if err != nil {
+		return nil, err
 	}
 
-	commandTag, err := p.Pool.Exec(ctx, query, args...)
+	after, err := scanCompany(tx.QueryRow(ctx, query, args...))
 	if err != nil {
 		var constraintError *pgconn.PgError
-		if _, nameChanged := fieldsToUpdate["name"]; nameChanged &&
+		if patch.Name != nil &&
 			errors.As(err, &constraintError) &&
 			constraintError.Code == pgerrcode.UniqueViolation {
-			return domain.NewNameAlreadyTakenError(fieldsToUpdate["name"].(string))
+			return nil, domain.NewNameAlreadyTakenError(*patch.Name)
 		}
 
-		return fmt.Errorf("update company: error executing query: %w", err)
+		return nil, fmt.Errorf("update company: error executing query: %w", err)
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		return domain.NewCompanyNotFoundError(id)
+	if err := p.insertHistory(ctx, tx, domain.OperationUpdate, id, before, after); err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err := p.insertOutbox(ctx, tx, event); err != nil {
+		return nil, err
+	}
+
+	return after, nil
 }
 
-func (p Postgres) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+func (p Postgres) deleteCompany(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	version int,
+	event *ports.CompanyMutationEvent,
+) error {
 	query, args, err := p.Builder.
-		Delete(companyTable).
+		Update(companyTable).
+		Set("deleted_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
 		Where("id = ?", id).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("delete company: error building query: %w", err)
 	}
 
-	commandTag, err := p.Pool.Exec(ctx, query, args...)
+	before, err := p.getCompanyForUpdate(ctx, tx, id, version)
 	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
 		return fmt.Errorf("delete company: error executing query: %w", err)
 	}
 
-	if commandTag.RowsAffected() == 0 {
-		return domain.NewCompanyNotFoundError(id)
+	if err := p.insertHistory(ctx, tx, domain.OperationDelete, id, before, nil); err != nil {
+		return err
+	}
+
+	return p.insertOutbox(ctx, tx, event)
+}
+
+func (p Postgres) RestoreCompany(
+	ctx context.Context,
+	id uuid.UUID,
+	event *ports.CompanyMutationEvent,
+) (*domain.Company, error) {
+	selectQuery, selectArgs, err := p.Builder.
+		Select(companyColumns).
+		From(companyTable).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("restore company: error building query: %w", err)
+	}
+
+	restoreQuery, restoreArgs, err := p.Builder.
+		Update(companyTable).
+		Set("deleted_at", nil).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("restore company: error building query: %w", err)
+	}
+
+	var company *domain.Company
+
+	err = p.inTx(ctx, "restore company", func(tx pgx.Tx) error {
+		company, err = scanCompany(tx.QueryRow(ctx, selectQuery, selectArgs...))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.NewCompanyNotFoundError(id)
+			}
+
+			return fmt.Errorf("restore company: error scanning row: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, restoreQuery, restoreArgs...); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+				return domain.NewNameAlreadyTakenError(company.Name)
+			}
+
+			return fmt.Errorf("restore company: error executing query: %w", err)
+		}
+
+		company.Version++
+
+		if err := p.insertHistory(ctx, tx, domain.OperationRestore, id, nil, company); err != nil {
+			return err
+		}
+
+		return p.insertOutbox(ctx, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return company, nil
+}
+
+func (p Postgres) ListPurgeableCompanies(ctx context.Context, deletedBefore time.Time, limit int) ([]uuid.UUID, error) {
+	query, args, err := p.Builder.
+		Select("id").
+		From(companyTable).
+		Where("deleted_at < ?", deletedBefore).
+		OrderBy("deleted_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("list purgeable companies: error building query: %w", err)
+	}
+
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list purgeable companies: error executing query: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("list purgeable companies: error scanning rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// PurgeCompany permanently removes the deleted company, its history is preserved.
+func (p Postgres) PurgeCompany(ctx context.Context, id uuid.UUID, event *ports.CompanyMutationEvent) error {
+	query, args, err := p.Builder.
+		Delete(companyTable).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Suffix("RETURNING " + companyColumns).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("purge company: error building query: %w", err)
+	}
+
+	return p.inTx(ctx, "purge company", func(tx pgx.Tx) error {
+		before, err := scanCompany(tx.QueryRow(ctx, query, args...))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.NewCompanyNotFoundError(id)
+			}
+
+			return fmt.Errorf("purge company: error executing query: %w", err)
+		}
+
+		if err := p.insertHistory(ctx, tx, domain.OperationPurge, id, before, nil); err != nil {
+			return err
+		}
+
+		return p.insertOutbox(ctx, tx, event)
+	})
+}
+
+// getCompanyForUpdate fetches the company and locks its row until the end of the transaction.
+// Returns domain.VersionMismatchError if version is not zero and doesn't match the current one.
+func (p Postgres) getCompanyForUpdate(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uuid.UUID,
+	version int,
+) (*domain.Company, error) {
+	query, args, err := p.Builder.
+		Select(companyColumns).
+		From(companyTable).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("get company for update: error building query: %w", err)
+	}
+
+	company, err := scanCompany(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewCompanyNotFoundError(id)
+		}
+
+		return nil, fmt.Errorf("get company for update: error scanning row: %w", err)
+	}
+
+	if version != 0 && company.Version != version {
+		return nil, domain.NewVersionMismatchError(id, version, company.Version)
+	}
+
+	return company, nil
+}
+
+// inTx runs fn within a single transaction and commits it if fn succeeds.
+// Errors returned by fn are passed through as is.
+func (p Postgres) inTx(ctx context.Context, op string, fn func(tx pgx.Tx) error) error {
+	tx, err := p.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error starting transaction: %w", op, err)
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: error committing transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// inSavepoint runs fn within a savepoint of tx, so its failure rolls back only the changes made by fn.
+// Errors returned by fn are passed through as is.
+func (p Postgres) inSavepoint(ctx context.Context, tx pgx.Tx, op string, fn func(tx pgx.Tx) error) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error creating savepoint: %w", op, err)
+	}
+
+	defer func() {
+		_ = savepoint.Rollback(ctx)
+	}()
+
+	if err := fn(savepoint); err != nil {
+		return err
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: error releasing savepoint: %w", op, err)
 	}
 
 	return nil
 }
+
+// isCompanyError reports whether err is a user-friendly error of the company mutation.
+func isCompanyError(err error) bool {
+	var (
+		companyNotFoundErr  *domain.CompanyNotFoundError
+		nameAlreadyTakenErr *domain.NameAlreadyTakenError
+		versionMismatchErr  *domain.VersionMismatchError
+	)
+
+	return errors.As(err, &companyNotFoundErr) ||
+		errors.As(err, &nameAlreadyTakenErr) ||
+		errors.As(err, &versionMismatchErr)
+}
+
+// scanCompany scans a row selected with companyColumns.
+func scanCompany(row pgx.Row) (*domain.Company, error) {
+	company := new(domain.Company)
+
+	err := row.Scan(
+		&company.ID,
+		&company.Name,
+		&company.Description,
+		&company.EmployeeCnt,
+		&company.Registered,
+		&company.Type,
+		&company.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return company, nil
+}
+
+// escapeLike escapes LIKE pattern special characters, so the value is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
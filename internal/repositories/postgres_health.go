@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/jackc/pgx/v5"
+)
+
+// migrationsURL is where migrations are read from, relative to the working directory of the app.
+const migrationsURL = "file://migrations"
+
+// Ping checks that the database is reachable through the pool.
+func (p Postgres) Ping(ctx context.Context) error {
+	if err := p.Pool.Ping(ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	return nil
+}
+
+// CheckMigrations checks that the latest migration is applied and isn't left dirty by a failed run.
+func (p Postgres) CheckMigrations(ctx context.Context) error {
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		return fmt.Errorf("check migrations: %w", err)
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err = p.Pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("check migrations: no migrations applied")
+	}
+
+	if err != nil {
+		return fmt.Errorf("check migrations: error scanning row: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("check migrations: migration %d is dirty", version)
+	}
+
+	if uint(version) < latest {
+		return fmt.Errorf("check migrations: migration %d is applied, latest is %d", version, latest)
+	}
+
+	return nil
+}
+
+func latestMigrationVersion() (uint, error) {
+	driver, err := source.Open(migrationsURL)
+	if err != nil {
+		return 0, fmt.Errorf("error opening migrations: %w", err)
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("error reading migrations: %w", err)
+		}
+
+		version = next
+	}
+}
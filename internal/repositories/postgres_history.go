@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	historyTable = "company_history"
+)
+
+// insertHistory records a company change as a part of the mutation transaction.
+// The actor is taken from the context, see domain.WithActor.
+func (p Postgres) insertHistory(
+	ctx context.Context,
+	tx pgx.Tx,
+	operation string,
+	id uuid.UUID,
+	before, after *domain.Company,
+) error {
+	beforeData, err := marshalNullable(before)
+	if err != nil {
+		return fmt.Errorf("insert history: error serializing company: %w", err)
+	}
+
+	afterData, err := marshalNullable(after)
+	if err != nil {
+		return fmt.Errorf("insert history: error serializing company: %w", err)
+	}
+
+	query, args, err := p.Builder.
+		Insert(historyTable).
+		Columns("company_id, operation, actor, before, after").
+		Values(id, operation, domain.ActorFromContext(ctx), beforeData, afterData).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("insert history: error building query: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert history: error executing query: %w", err)
+	}
+
+	return nil
+}
+
+func (p Postgres) GetCompanyHistory(ctx context.Context, id uuid.UUID) ([]*domain.CompanyVersion, error) {
+	query, args, err := p.Builder.
+		Select("operation, actor, changed_at, before, after").
+		From(historyTable).
+		Where("company_id = ?", id).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("get company history: error building query: %w", err)
+	}
+
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get company history: error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*domain.CompanyVersion
+
+	for rows.Next() {
+		var beforeData, afterData []byte
+
+		version := new(domain.CompanyVersion)
+		if err := rows.Scan(&version.Operation, &version.Actor, &version.ChangedAt, &beforeData, &afterData); err != nil {
+			return nil, fmt.Errorf("get company history: error scanning row: %w", err)
+		}
+
+		if version.Before, err = unmarshalNullable(beforeData); err != nil {
+			return nil, fmt.Errorf("get company history: error deserializing company: %w", err)
+		}
+
+		if version.After, err = unmarshalNullable(afterData); err != nil {
+			return nil, fmt.Errorf("get company history: error deserializing company: %w", err)
+		}
+
+		version.Version = domain.ChangedVersion(version.Operation, version.Before, version.After)
+		history = append(history, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get company history: error reading rows: %w", err)
+	}
+
+	return history, nil
+}
+
+// marshalNullable serializes company into JSON, nil company is stored as NULL.
+func marshalNullable(company *domain.Company) ([]byte, error) {
+	if company == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(company)
+}
+
+func unmarshalNullable(data []byte) (*domain.Company, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	company := new(domain.Company)
+	if err := json.Unmarshal(data, company); err != nil {
+		return nil, err
+	}
+
+	return company, nil
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	idempotencyKeyTable = "idempotency_key"
+	// idempotencyReserveAttempts limits retries of reservation racing with the release of the same key.
+	idempotencyReserveAttempts = 3
+)
+
+func (p Postgres) ReserveIdempotencyKey(
+	ctx context.Context,
+	key, fingerprint string,
+	lockTimeout time.Duration,
+) (*ports.IdempotencyRecord, bool, error) {
+	expiresAt := time.Now().Add(lockTimeout)
+
+	// expired records are taken over as if they didn't exist
+	insertQuery, insertArgs, err := p.Builder.
+		Insert(idempotencyKeyTable).
+		Columns("key, fingerprint, expires_at").
+		Values(key, fingerprint, expiresAt).
+		Suffix(`ON CONFLICT (key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint, status_code = NULL, header = NULL, body = NULL,
+			created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_key.expires_at < now()`).
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("reserve idempotency key: error building query: %w", err)
+	}
+
+	selectQuery, selectArgs, err := p.Builder.
+		Select("fingerprint, status_code, header, body").
+		From(idempotencyKeyTable).
+		Where("key = ?", key).
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("reserve idempotency key: error building query: %w", err)
+	}
+
+	for attempt := 0; attempt < idempotencyReserveAttempts; attempt++ {
+		tag, err := p.Pool.Exec(ctx, insertQuery, insertArgs...)
+		if err != nil {
+			return nil, false, fmt.Errorf("reserve idempotency key: error executing query: %w", err)
+		}
+
+		if tag.RowsAffected() == 1 {
+			return &ports.IdempotencyRecord{Key: key, Fingerprint: fingerprint}, true, nil
+		}
+
+		var (
+			statusCode *int
+			header     []byte
+		)
+
+		record := &ports.IdempotencyRecord{Key: key}
+
+		err = p.Pool.QueryRow(ctx, selectQuery, selectArgs...).
+			Scan(&record.Fingerprint, &statusCode, &header, &record.Body)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the key was released after the insert attempt, so it can be reserved again
+			continue
+		}
+
+		if err != nil {
+			return nil, false, fmt.Errorf("reserve idempotency key: error scanning row: %w", err)
+		}
+
+		if statusCode != nil {
+			record.Completed = true
+			record.StatusCode = *statusCode
+		}
+
+		if header != nil {
+			if err := json.Unmarshal(header, &record.Header); err != nil {
+				return nil, false, fmt.Errorf("reserve idempotency key: error deserializing header: %w", err)
+			}
+		}
+
+		return record, false, nil
+	}
+
+	return nil, false, fmt.Errorf("reserve idempotency key: key %s is being released concurrently", key)
+}
+
+func (p Postgres) CompleteIdempotencyKey(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	statusCode int,
+	header map[string]string,
+	body []byte,
+) error {
+	headerData, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: error serializing header: %w", err)
+	}
+
+	query, args, err := p.Builder.
+		Update(idempotencyKeyTable).
+		Set("status_code", statusCode).
+		Set("header", headerData).
+		Set("body", body).
+		Set("expires_at", time.Now().Add(ttl)).
+		Where("key = ?", key).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: error building query: %w", err)
+	}
+
+	if _, err := p.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("complete idempotency key: error executing query: %w", err)
+	}
+
+	return nil
+}
+
+func (p Postgres) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	query, args, err := p.Builder.
+		Delete(idempotencyKeyTable).
+		Where(squirrel.Eq{"key": key, "status_code": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("release idempotency key: error building query: %w", err)
+	}
+
+	if _, err := p.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("release idempotency key: error executing query: %w", err)
+	}
+
+	return nil
+}
+
+func (p Postgres) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	query, args, err := p.Builder.
+		Delete(idempotencyKeyTable).
+		Where("expires_at < now()").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: error building query: %w", err)
+	}
+
+	tag, err := p.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: error executing query: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxTable           = "company_outbox"
+	outboxDeadLetterTable = "company_outbox_dead_letter"
+
+	// outboxLease is the time fetched messages stay claimed by the relay which fetched them. Other relays
+	// skip claimed messages, so they are published twice only if the relay fails to deliver them in time.
+	outboxLease = time.Minute
+)
+
+// insertOutbox stores the event in the outbox as a part of the mutation transaction,
+// together with the trace context of the mutation, so the relayed event continues its trace.
+func (p Postgres) insertOutbox(ctx context.Context, tx pgx.Tx, event *ports.CompanyMutationEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("insert outbox: error serializing event: %w", err)
+	}
+
+	traceContext, err := json.Marshal(tracing.Inject(ctx))
+	if err != nil {
+		return fmt.Errorf("insert outbox: error serializing trace context: %w", err)
+	}
+
+	query, args, err := p.Builder.
+		Insert(outboxTable).
+		Columns("company_id, event, trace_context").
+		Values(event.CompanyID, payload, traceContext).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("insert outbox: error building query: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert outbox: error executing query: %w", err)
+	}
+
+	return nil
+}
+
+func (p Postgres) StoreOutbox(ctx context.Context, events ...*ports.CompanyMutationEvent) error {
+	return p.inTx(ctx, "store outbox", func(tx pgx.Tx) error {
+		for _, event := range events {
+			if err := p.insertOutbox(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FetchOutbox claims the oldest message of every company which is due for delivery. Messages locked
+// by concurrent fetches are skipped, so concurrent relays never claim the same messages. Messages which
+// can't be decoded are moved to the dead letters instead of being returned, so they don't block the relay.
+func (p Postgres) FetchOutbox(ctx context.Context, limit int) ([]*ports.OutboxMessage, error) {
+	due := p.Builder.
+		Select("o.id").
+		From(outboxTable + " o").
+		Where("o.next_attempt_at <= now()").
+		Where("NOT EXISTS (SELECT 1 FROM " + outboxTable + " p WHERE p.company_id = o.company_id AND p.id < o.id)").
+		OrderBy("o.id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query, args, err := p.Builder.
+		Update(outboxTable).
+		Set("next_attempt_at", squirrel.Expr("now() + ? * interval '1 millisecond'", outboxLease.Milliseconds())).
+		Where(squirrel.Expr("id IN (?)", due)).
+		Suffix("RETURNING id, event, trace_context, attempts").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("fetch outbox: error building query: %w", err)
+	}
+
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("fetch outbox: error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]*ports.OutboxMessage, 0, limit)
+	undecodable := make(map[int64]error)
+
+	for rows.Next() {
+		var payload, traceContext []byte
+
+		message := &ports.OutboxMessage{Event: new(ports.CompanyMutationEvent)}
+		if err := rows.Scan(&message.ID, &payload, &traceContext, &message.Attempts); err != nil {
+			return nil, fmt.Errorf("fetch outbox: error scanning row: %w", err)
+		}
+
+		if err := json.Unmarshal(payload, message.Event); err != nil {
+			undecodable[message.ID] = err
+			continue
+		}
+
+		// trace context only links the relayed event to the mutation, so the event is published without it
+		if traceContext != nil {
+			if err := json.Unmarshal(traceContext, &message.Event.TraceContext); err != nil {
+				p.logger.Warn("fetch outbox: error deserializing trace context", zap.Int64("id", message.ID), zap.Error(err))
+			}
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch outbox: error reading rows: %w", err)
+	}
+
+	for id, decodeErr := range undecodable {
+		p.logger.Error("fetch outbox: error deserializing event", zap.Int64("id", id), zap.Error(decodeErr))
+
+		// the message stays claimed and is moved on the next attempt after the claim expires
+		if err := p.deadLetterOutbox(ctx, id, decodeErr.Error()); err != nil {
+			p.logger.Error("fetch outbox: error moving event to dead letters", zap.Int64("id", id), zap.Error(err))
+		}
+	}
+
+	// updated rows are returned in no particular order
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages, nil
+}
+
+// deadLetterOutbox moves the message to the dead letters together with the reason it can't be relayed.
+func (p Postgres) deadLetterOutbox(ctx context.Context, id int64, reason string) error {
+	insertQuery, insertArgs, err := p.Builder.
+		Insert(outboxDeadLetterTable).
+		Columns("id, company_id, event, trace_context, attempts, created_at, reason").
+		Select(p.Builder.
+			Select("id, company_id, event, trace_context, attempts, created_at").
+			Column(squirrel.Expr("?::text", reason)).
+			From(outboxTable).
+			Where("id = ?", id)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("dead letter outbox: error building query: %w", err)
+	}
+
+	deleteQuery, deleteArgs, err := p.Builder.
+		Delete(outboxTable).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("dead letter outbox: error building query: %w", err)
+	}
+
+	return p.inTx(ctx, "dead letter outbox", func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, insertQuery, insertArgs...); err != nil {
+			return fmt.Errorf("dead letter outbox: error executing query: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, deleteQuery, deleteArgs...); err != nil {
+			return fmt.Errorf("dead letter outbox: error executing query: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (p Postgres) DeleteOutbox(ctx context.Context, ids ...int64) error {
+	query, args, err := p.Builder.
+		Delete(outboxTable).
+		Where(squirrel.Eq{"id": ids}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("delete outbox: error building query: %w", err)
+	}
+
+	if _, err := p.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("delete outbox: error executing query: %w", err)
+	}
+
+	return nil
+}
+
+func (p Postgres) RescheduleOutbox(ctx context.Context, id int64, nextAttemptAt time.Time, reason string) error {
+	query, args, err := p.Builder.
+		Update(outboxTable).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("last_error", reason).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("reschedule outbox: error building query: %w", err)
+	}
+
+	if _, err := p.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("reschedule outbox: error executing query: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/core/ports/portstest"
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testDSNEnv points at the database the Postgres conformance suite runs against,
+// every test truncates its tables, so it mustn't be used by anything else.
+const testDSNEnv = "TEST_DB_DSN"
+
+// newPostgres returns the migrated repository of the test database, the test is skipped if there is none.
+func newPostgres(t *testing.T) *repositories.Postgres {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s isn't set", testDSNEnv)
+	}
+
+	repo, err := repositories.NewPostgres(dsn, 20, 1, 1, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(repo.Pool.Close)
+
+	// migrations are read relatively to the root of the repository
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir("../.."))
+	require.NoError(t, repo.Migrate())
+	require.NoError(t, os.Chdir(wd))
+
+	return repo
+}
+
+// truncate removes everything stored in the test database.
+func truncate(t *testing.T, repo *repositories.Postgres) {
+	t.Helper()
+
+	_, err := repo.Pool.Exec(
+		context.Background(),
+		"TRUNCATE company, company_history, company_outbox, company_outbox_dead_letter, idempotency_key RESTART IDENTITY",
+	)
+	require.NoError(t, err)
+}
+
+func TestPostgres_Conformance(t *testing.T) {
+	repo := newPostgres(t)
+
+	portstest.RunRepository(t, func(t *testing.T) ports.Repository {
+		truncate(t, repo)
+
+		return repo
+	})
+}
+
+func TestPostgres_UndecodableOutbox(t *testing.T) {
+	repo := newPostgres(t)
+	truncate(t, repo)
+
+	store := func(companyID uuid.UUID, payload []byte) {
+		_, err := repo.Pool.Exec(
+			context.Background(),
+			"INSERT INTO company_outbox (company_id, event) VALUES ($1, $2)",
+			companyID,
+			payload,
+		)
+		require.NoError(t, err)
+	}
+
+	testUndecodableOutbox(t, repo, store, func() int {
+		var deadLetters int
+
+		err := repo.Pool.QueryRow(context.Background(), "SELECT count(*) FROM company_outbox_dead_letter").Scan(&deadLetters)
+		require.NoError(t, err)
+
+		return deadLetters
+	})
+}
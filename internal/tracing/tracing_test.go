@@ -0,0 +1,55 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dimaglushkov/epam-xm-test-assignment/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), "unknown", "", "app", "0.0.1", 1)
+	assert.Error(t, err)
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.ExporterNone, "", "app", "0.0.1", 1)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+
+	headers := tracing.Inject(ctx)
+	assert.Contains(t, headers, "traceparent")
+
+	extracted := trace.SpanContextFromContext(tracing.Extract(context.Background(), headers))
+	assert.Equal(t, parent.SpanContext().TraceID(), extracted.TraceID())
+	assert.Equal(t, parent.SpanContext().SpanID(), extracted.SpanID())
+
+	queryTracer := tracing.NewQueryTracer()
+
+	// queries outside of traces are skipped
+	queryCtx := queryTracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	queryTracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+	assert.Empty(t, recorder.Ended())
+
+	queryCtx = queryTracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "update company set name = $1"})
+	queryTracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("deadlock detected")})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "postgres UPDATE", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
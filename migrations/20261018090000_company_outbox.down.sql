@@ -0,0 +1 @@
+DROP TABLE IF EXISTS company_outbox;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Events are stored in the outbox within the same transaction as the company mutation
+-- and then relayed to the message broker in the order of their ids.
+CREATE TABLE IF NOT EXISTS company_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    company_id UUID NOT NULL,
+    event JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS company_history;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Every version of a company is recorded within the mutation transaction,
+-- existing companies are recorded as snapshots, so their history has a starting point.
+CREATE TABLE IF NOT EXISTS company_history (
+    id BIGSERIAL PRIMARY KEY,
+    company_id UUID NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX IF NOT EXISTS company_history_company_id_idx ON company_history (company_id, id);
+
+INSERT INTO company_history (company_id, operation, after)
+SELECT id, 'snapshot', to_jsonb(company) FROM company;
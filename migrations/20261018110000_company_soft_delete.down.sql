@@ -0,0 +1,7 @@
+DELETE FROM company WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS company_deleted_at_idx;
+DROP INDEX IF EXISTS company_name_idx;
+
+ALTER TABLE company ADD CONSTRAINT company_name_key UNIQUE (name);
+ALTER TABLE company DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Deleted companies are kept until they are purged, so names have to be unique among active companies only.
+ALTER TABLE company ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE company DROP CONSTRAINT IF EXISTS company_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS company_name_idx ON company (name) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS company_deleted_at_idx ON company (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Version is incremented by every mutation and used for optimistic concurrency control.
+ALTER TABLE company ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
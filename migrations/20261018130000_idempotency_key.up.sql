@@ -0,0 +1,13 @@
+-- Responses of requests made with Idempotency-Key header, replayed when the request is repeated.
+-- Rows without status_code belong to requests which are still being processed.
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    key VARCHAR(512) PRIMARY KEY,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INT,
+    header JSONB,
+    body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_at_idx ON idempotency_key (expires_at);
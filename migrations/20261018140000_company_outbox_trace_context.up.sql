@@ -0,0 +1,2 @@
+-- W3C trace context of the mutation, passed in the headers of the relayed message.
+ALTER TABLE company_outbox ADD COLUMN IF NOT EXISTS trace_context JSONB;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS company_outbox_company_id_idx;
\ No newline at end of file
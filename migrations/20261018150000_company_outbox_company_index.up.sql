@@ -0,0 +1,2 @@
+-- The oldest pending message of every company is relayed first, so messages are looked up by companies.
+CREATE INDEX IF NOT EXISTS company_outbox_company_id_idx ON company_outbox (company_id, id);
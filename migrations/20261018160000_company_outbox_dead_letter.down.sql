@@ -0,0 +1 @@
+DROP TABLE IF EXISTS company_outbox_dead_letter;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Outbox messages which can't be relayed are moved here, so they don't block the relay.
+CREATE TABLE IF NOT EXISTS company_outbox_dead_letter (
+    id BIGINT PRIMARY KEY,
+    company_id UUID NOT NULL,
+    event JSONB NOT NULL,
+    trace_context JSONB,
+    attempts INT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    dead_lettered_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);